@@ -83,6 +83,38 @@ export namespace Config {
         .string()
         .optional()
         .describe("Toggle compact mode for session"),
+      session_queue: z.string().optional().describe("Edit queued prompts"),
+      session_export: z
+        .string()
+        .optional()
+        .describe("Export the session to a file"),
+      session_import: z
+        .string()
+        .optional()
+        .describe("Import a session from a file"),
+      session_usage: z
+        .string()
+        .optional()
+        .describe("Show token and cost usage"),
+      session_retry: z.string().optional().describe("Retry the last prompt"),
+      session_retry_model: z
+        .string()
+        .optional()
+        .describe("Retry the last prompt with another model"),
+      session_continue: z
+        .string()
+        .optional()
+        .describe("Continue a cut off reply"),
+      tab_next: z.string().optional().describe("Switch to the next tab"),
+      tab_previous: z
+        .string()
+        .optional()
+        .describe("Switch to the previous tab"),
+      tab_close: z.string().optional().describe("Close the current tab"),
+      shell_clear: z
+        .string()
+        .optional()
+        .describe("Discard shell output waiting to be sent"),
       tool_details: z.string().optional().describe("Show tool details"),
       model_list: z.string().optional().describe("List available models"),
       theme_list: z.string().optional().describe("List available themes"),
@@ -94,6 +126,10 @@ export namespace Config {
       input_paste: z.string().optional().describe("Paste from clipboard"),
       input_submit: z.string().optional().describe("Submit input"),
       input_newline: z.string().optional().describe("Insert newline in input"),
+      input_history: z
+        .string()
+        .optional()
+        .describe("Search the prompt history"),
       history_previous: z
         .string()
         .optional()
@@ -128,12 +164,37 @@ export namespace Config {
         .optional()
         .describe("Navigate to first message"),
       messages_last: z.string().optional().describe("Navigate to last message"),
+      messages_redo: z.string().optional().describe("Redo reverted messages"),
+      messages_fork: z
+        .string()
+        .optional()
+        .describe("Fork the session from the selected message"),
+      messages_open_task: z
+        .string()
+        .optional()
+        .describe("Open the session of the selected task"),
       app_exit: z.string().optional().describe("Exit the application"),
     })
     .strict()
     .openapi({
       ref: "KeybindsConfig",
     })
+  export const Budget = z
+    .object({
+      warn: z
+        .number()
+        .optional()
+        .describe("Spend in USD at which the TUI shows a warning"),
+      limit: z
+        .number()
+        .optional()
+        .describe("Spend in USD past which prompts need confirming"),
+    })
+    .strict()
+    .openapi({
+      ref: "BudgetConfig",
+    })
+
   export const Info = z
     .object({
       $schema: z
@@ -153,6 +214,15 @@ export namespace Config {
         .boolean()
         .optional()
         .describe("Automatically update to the latest version"),
+      autocompact: z
+        .number()
+        .int()
+        .min(0)
+        .max(100)
+        .optional()
+        .describe(
+          "Compact a session once its context fills this percentage of the model's context window, 0 disables it",
+        ),
       disabled_providers: z
         .array(z.string())
         .optional()
@@ -176,6 +246,36 @@ export namespace Config {
         .record(z.string(), Mcp)
         .optional()
         .describe("MCP (Model Context Protocol) server configurations"),
+      notifications: z
+        .object({
+          method: z
+            .enum(["bell", "osc9", "osc777", "none"])
+            .optional()
+            .describe(
+              "How the TUI notifies: the terminal bell, an OSC 9 or OSC 777 desktop notification, or not at all. Defaults to bell",
+            ),
+          command: z
+            .string()
+            .optional()
+            .describe(
+              "Shell command run on each notification, with OPENCODE_NOTIFY_TITLE and OPENCODE_NOTIFY_BODY set",
+            ),
+        })
+        .strict()
+        .optional()
+        .describe(
+          "Notifications when a reply finishes or fails while the terminal is unfocused",
+        ),
+      budget: z
+        .object({
+          session: Budget.optional().describe("Cost budget of each session"),
+          daily: Budget.optional().describe(
+            "Cost budget of each day, across sessions",
+          ),
+        })
+        .strict()
+        .optional()
+        .describe("Cost budgets enforced by the TUI"),
       instructions: z
         .array(z.string())
         .optional()
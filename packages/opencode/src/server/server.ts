@@ -221,6 +221,34 @@ export namespace Server {
           return c.json(session)
         },
       )
+      .post(
+        "/session/import",
+        describeRoute({
+          description:
+            "Create a new session from the messages of another, e.g. an exported transcript",
+          responses: {
+            200: {
+              description: "Imported session",
+              content: {
+                "application/json": {
+                  schema: resolver(Session.Info),
+                },
+              },
+            },
+          },
+        }),
+        zValidator(
+          "json",
+          z.object({
+            title: z.string().min(1),
+            messages: Message.Info.array(),
+          }),
+        ),
+        async (c) => {
+          const body = c.req.valid("json")
+          return c.json(await Session.importMessages(body))
+        },
+      )
       .delete(
         "/session/:id",
         describeRoute({
@@ -247,6 +275,43 @@ export namespace Server {
           return c.json(true)
         },
       )
+      .patch(
+        "/session/:id",
+        describeRoute({
+          description: "Update session properties",
+          responses: {
+            200: {
+              description: "Updated session",
+              content: {
+                "application/json": {
+                  schema: resolver(Session.Info),
+                },
+              },
+            },
+          },
+        }),
+        zValidator(
+          "param",
+          z.object({
+            id: z.string(),
+          }),
+        ),
+        zValidator(
+          "json",
+          z.object({
+            title: z.string().min(1).optional(),
+          }),
+        ),
+        async (c) => {
+          const id = c.req.valid("param").id
+          const body = c.req.valid("json")
+          const session = await Session.update(id, (draft) => {
+            if (body.title !== undefined) draft.title = body.title
+          })
+          if (!session) return c.json({ error: "session not found" }, 404)
+          return c.json(session)
+        },
+      )
       .post(
         "/session/:id/init",
         describeRoute({
@@ -398,6 +463,103 @@ export namespace Server {
           return c.json(true)
         },
       )
+      .post(
+        "/session/:id/revert",
+        describeRoute({
+          description:
+            "Revert the session to before a message, restoring the files it changed",
+          responses: {
+            200: {
+              description: "Reverted session",
+              content: {
+                "application/json": {
+                  schema: resolver(Session.Info),
+                },
+              },
+            },
+          },
+        }),
+        zValidator(
+          "param",
+          z.object({
+            id: z.string().openapi({ description: "Session ID" }),
+          }),
+        ),
+        zValidator(
+          "json",
+          z.object({
+            messageID: z.string(),
+            part: z.number(),
+          }),
+        ),
+        async (c) => {
+          const id = c.req.valid("param").id
+          const body = c.req.valid("json")
+          await Session.revert({ ...body, sessionID: id })
+          return c.json(await Session.get(id))
+        },
+      )
+      .post(
+        "/session/:id/unrevert",
+        describeRoute({
+          description: "Undo a revert, restoring the reverted messages and files",
+          responses: {
+            200: {
+              description: "Restored session",
+              content: {
+                "application/json": {
+                  schema: resolver(Session.Info),
+                },
+              },
+            },
+          },
+        }),
+        zValidator(
+          "param",
+          z.object({
+            id: z.string().openapi({ description: "Session ID" }),
+          }),
+        ),
+        async (c) => {
+          const id = c.req.valid("param").id
+          await Session.unrevert(id)
+          return c.json(await Session.get(id))
+        },
+      )
+      .post(
+        "/session/:id/fork",
+        describeRoute({
+          description:
+            "Create a new session with the messages of this one up to and including a message",
+          responses: {
+            200: {
+              description: "Forked session",
+              content: {
+                "application/json": {
+                  schema: resolver(Session.Info),
+                },
+              },
+            },
+          },
+        }),
+        zValidator(
+          "param",
+          z.object({
+            id: z.string().openapi({ description: "Session ID" }),
+          }),
+        ),
+        zValidator(
+          "json",
+          z.object({
+            messageID: z.string(),
+          }),
+        ),
+        async (c) => {
+          const id = c.req.valid("param").id
+          const body = c.req.valid("json")
+          return c.json(await Session.fork({ ...body, sessionID: id }))
+        },
+      )
       .get(
         "/session/:id/message",
         describeRoute({
@@ -43,6 +43,12 @@ export namespace Session {
     .object({
       id: Identifier.schema("session"),
       parentID: Identifier.schema("session").optional(),
+      forkedFrom: z
+        .object({
+          sessionID: Identifier.schema("session"),
+          messageID: z.string(),
+        })
+        .optional(),
       share: z
         .object({
           url: z.string(),
@@ -820,11 +826,49 @@ export namespace Session {
     if (!session.revert) return
     if (session.revert.snapshot)
       await Snapshot.restore(sessionID, session.revert.snapshot)
-    update(sessionID, (draft) => {
+    await update(sessionID, (draft) => {
       draft.revert = undefined
     })
   }
 
+  export async function fork(input: { sessionID: string; messageID: string }) {
+    const parent = await get(input.sessionID)
+    // a fork is a session of its own, parentID is for the sessions of tasks
+    const session = await create()
+    for (const msg of await messages(input.sessionID)) {
+      if (msg.id > input.messageID) break
+      await updateMessage({
+        ...msg,
+        metadata: { ...msg.metadata, sessionID: session.id },
+      })
+    }
+    return (await update(session.id, (draft) => {
+      draft.title = "Fork of " + parent.title
+      draft.forkedFrom = {
+        sessionID: input.sessionID,
+        messageID: input.messageID,
+      }
+    }))!
+  }
+
+  export async function importMessages(input: {
+    title: string
+    messages: Message.Info[]
+  }) {
+    const session = await create()
+    for (const msg of input.messages) {
+      // fresh ids keep the messages ordered after anything stored before
+      await updateMessage({
+        ...msg,
+        id: Identifier.ascending("message"),
+        metadata: { ...msg.metadata, sessionID: session.id },
+      })
+    }
+    return (await update(session.id, (draft) => {
+      draft.title = input.title
+    }))!
+  }
+
   export async function summarize(input: {
     sessionID: string
     providerID: string
@@ -37,6 +37,7 @@ export const TaskTool = Tool.define({
       if (evt.properties.info.metadata.sessionID !== session.id) return
       ctx.metadata({
         title: params.description,
+        sessionID: session.id,
         summary: summary(evt.properties.info),
       })
     })
@@ -59,6 +60,7 @@ export const TaskTool = Tool.define({
     return {
       metadata: {
         title: params.description,
+        sessionID: session.id,
         summary: summary(result),
       },
       output: result.parts.findLast((x) => x.type === "text")!.text,
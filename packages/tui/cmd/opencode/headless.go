@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/util"
+)
+
+// readPrompt returns the prompt passed as positional arguments, falling back
+// to stdin when it is piped in.
+func readPrompt(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return "", err
+	}
+	if stat.Mode()&os.ModeCharDevice != 0 {
+		return "", nil
+	}
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// runCmd executes a tea.Cmd outside of a tea.Program, flattening batches, and
+// returns every message it produced.
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	switch msg := msg.(type) {
+	case nil:
+		return nil
+	case tea.BatchMsg:
+		var msgs []tea.Msg
+		for _, cmd := range msg {
+			msgs = append(msgs, runCmd(cmd)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+// runHeadless sends a single prompt, prints the assistant's reply to stdout as
+// it streams in, errors to stderr, and returns the process exit code.
+func runHeadless(ctx context.Context, app_ *app.App, prompt string, stdout, stderr io.Writer) int {
+	selected, ok := app_.InitializeProvider()().(app.ModelSelectedMsg)
+	if !ok {
+		fmt.Fprintln(stderr, "error: no providers configured")
+		return 1
+	}
+	app_.Provider = &selected.Provider
+	app_.Model = &selected.Model
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// earlier replies of a resumed session must not be taken for the answer
+	// to the new prompt
+	previous := make(map[string]bool)
+	if app_.Session.ID == "" {
+		session, err := app_.CreateSession(ctx)
+		if err != nil {
+			fmt.Fprintln(stderr, "error: failed to create session:", err)
+			return 1
+		}
+		app_.Session = session
+	} else {
+		messages, err := app_.ListMessages(ctx, app_.Session.ID)
+		if err != nil {
+			fmt.Fprintln(stderr, "error: failed to load session:", err)
+			return 1
+		}
+		for _, message := range messages {
+			previous[message.ID] = true
+		}
+	}
+
+	events := make(chan tea.Msg, 64)
+	go app_.StreamEvents(ctx, func(msg tea.Msg) {
+		select {
+		case events <- msg:
+		case <-ctx.Done():
+		}
+	})
+
+	failures := make(chan string, 1)
+	send := func() {
+		for _, msg := range runCmd(app_.SendChatMessage(ctx, prompt, nil)) {
+			switch msg := msg.(type) {
+			case toast.ShowToastMsg:
+				failures <- msg.Message
+				return
+			case app.BudgetExceededMsg:
+				// nobody can confirm going past the limit
+				failures <- fmt.Sprintf("%s spend is $%.2f, the limit is $%.2f", msg.Budget, msg.Spent, msg.Limit)
+				return
+			}
+		}
+	}
+
+	printer := newHeadlessPrinter(stdout)
+	// handle prints an assistant message and reports the exit code once
+	// the turn is complete
+	handle := func(message opencode.Message) (int, bool) {
+		if message.Metadata.SessionID != app_.Session.ID ||
+			message.Role != opencode.MessageRoleAssistant ||
+			previous[message.ID] {
+			return 0, false
+		}
+		printer.Print(message)
+		if message.Metadata.Time.Completed == 0 {
+			return 0, false
+		}
+		printer.Finish()
+		if description := errorDescription(message.Metadata.Error); description != "" {
+			fmt.Fprintln(stderr, "error:", description)
+			return 1, true
+		}
+		return 0, true
+	}
+
+	sent := false
+	for {
+		select {
+		case message := <-failures:
+			printer.Finish()
+			fmt.Fprintln(stderr, "error:", message)
+			return 1
+		case msg := <-events:
+			switch msg := msg.(type) {
+			case app.ConnectionStatusMsg:
+				// the prompt is only sent once subscribed, so that nothing
+				// published in response to it can be missed
+				if msg.Connected && !sent {
+					sent = true
+					go send()
+				}
+				if !msg.Connected && !sent {
+					fmt.Fprintln(stderr, "error: failed to connect to event stream:", msg.Err)
+					return 1
+				}
+			case app.ReconnectedMsg:
+				messages, err := app_.ListMessages(ctx, app_.Session.ID)
+				if err != nil {
+					slog.Error("Failed to resync messages", "error", err)
+					continue
+				}
+				for _, message := range messages {
+					if code, done := handle(message); done {
+						return code
+					}
+				}
+			case opencode.EventListResponseEventMessageUpdated:
+				if code, done := handle(msg.Properties.Info); done {
+					return code
+				}
+			case opencode.EventListResponseEventSessionError:
+				printer.Finish()
+				fmt.Fprintln(stderr, "error:", sessionErrorDescription(msg.Properties.Error))
+				return 1
+			}
+		}
+	}
+}
+
+func errorDescription(err opencode.MessageMetadataError) string {
+	switch err := err.AsUnion().(type) {
+	case opencode.MessageMetadataErrorMessageOutputLengthError:
+		return "Message output length exceeded"
+	case opencode.ProviderAuthError:
+		return err.Data.Message
+	case opencode.UnknownError:
+		return err.Data.Message
+	}
+	return ""
+}
+
+func sessionErrorDescription(err opencode.EventListResponseEventSessionErrorPropertiesError) string {
+	switch err := err.AsUnion().(type) {
+	case opencode.EventListResponseEventSessionErrorPropertiesErrorMessageOutputLengthError:
+		return "Message output length exceeded"
+	case opencode.ProviderAuthError:
+		return err.Data.Message
+	case opencode.UnknownError:
+		return err.Data.Message
+	}
+	return string(err.Name)
+}
+
+// headlessPrinter writes the parts of a streaming assistant message to a
+// plain writer, emitting only what hasn't been written yet.
+type headlessPrinter struct {
+	out         io.Writer
+	printedText map[string]int
+	printedTool map[string]bool
+	lineOpen    bool
+}
+
+func newHeadlessPrinter(out io.Writer) *headlessPrinter {
+	return &headlessPrinter{
+		out:         out,
+		printedText: make(map[string]int),
+		printedTool: make(map[string]bool),
+	}
+}
+
+func (p *headlessPrinter) Print(message opencode.Message) {
+	for i, part := range message.Parts {
+		switch part := part.AsUnion().(type) {
+		case opencode.TextPart:
+			key := fmt.Sprintf("%s:%d", message.ID, i)
+			printed := p.printedText[key]
+			if len(part.Text) <= printed {
+				continue
+			}
+			delta := part.Text[printed:]
+			fmt.Fprint(p.out, delta)
+			p.printedText[key] = len(part.Text)
+			p.lineOpen = !strings.HasSuffix(delta, "\n")
+		case opencode.ToolInvocationPart:
+			invocation := part.ToolInvocation
+			if invocation.State != "result" || p.printedTool[invocation.ToolCallID] {
+				continue
+			}
+			p.printedTool[invocation.ToolCallID] = true
+			p.Finish()
+			fmt.Fprintf(p.out, "\n∟ %s\n", toolTitle(invocation))
+			if result := strings.TrimSpace(invocation.Result); result != "" {
+				fmt.Fprintln(p.out, util.TruncateHeight(result, 10))
+			}
+			fmt.Fprintln(p.out)
+		}
+	}
+}
+
+// Finish terminates a partially written line.
+func (p *headlessPrinter) Finish() {
+	if p.lineOpen {
+		fmt.Fprintln(p.out)
+		p.lineOpen = false
+	}
+}
+
+func toolTitle(invocation opencode.ToolInvocationPartToolInvocation) string {
+	args, _ := invocation.Args.(map[string]any)
+	for _, key := range []string{"description", "filePath", "path", "pattern", "url", "command"} {
+		if value, ok := args[key].(string); ok && value != "" {
+			if key == "filePath" || key == "path" {
+				value = util.Relative(value)
+			}
+			return invocation.ToolName + " " + value
+		}
+	}
+	return invocation.ToolName
+}
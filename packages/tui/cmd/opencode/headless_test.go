@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+// newTestApp creates an app talking to server, keeping its state in a
+// temporary directory.
+func newTestApp(t *testing.T, server *testserver.Server) *app.App {
+	t.Helper()
+	app_, err := app.New(context.Background(), "test", testserver.AppIn(t.TempDir()), server.Client())
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	return app_
+}
+
+func TestHeadlessPrinter(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		messages []opencode.Message
+		want     string
+	}{
+		{
+			name: "streamed text",
+			messages: []opencode.Message{
+				testserver.AssistantMessage("msg_1", "ses_1", "Hel", false),
+				testserver.AssistantMessage("msg_1", "ses_1", "Hello,", false),
+				testserver.AssistantMessage("msg_1", "ses_1", "Hello, world\n", true),
+			},
+			want: "Hello, world\n",
+		},
+		{
+			name: "open line",
+			messages: []opencode.Message{
+				testserver.AssistantMessage("msg_1", "ses_1", "No newline", true),
+			},
+			want: "No newline\n",
+		},
+		{
+			name: "repeated update",
+			messages: []opencode.Message{
+				testserver.AssistantMessage("msg_1", "ses_1", "Once\n", true),
+				testserver.AssistantMessage("msg_1", "ses_1", "Once\n", true),
+			},
+			want: "Once\n",
+		},
+		{
+			name: "tool call",
+			messages: []opencode.Message{
+				testserver.TaskMessage("msg_1", "ses_1", "ses_2", "Explore"),
+				testserver.TaskMessage("msg_1", "ses_1", "ses_2", "Explore"),
+			},
+			want: "Let me delegate that.\n\n∟ task Explore\nDone.\n\n",
+		},
+		{
+			name: "two replies",
+			messages: []opencode.Message{
+				testserver.AssistantMessage("msg_1", "ses_1", "First\n", true),
+				testserver.AssistantMessage("msg_2", "ses_1", "Second\n", true),
+			},
+			want: "First\nSecond\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printer := newHeadlessPrinter(&out)
+			for _, message := range tt.messages {
+				printer.Print(message)
+			}
+			printer.Finish()
+			if out.String() != tt.want {
+				t.Errorf("printed %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunHeadless(t *testing.T) {
+	existing := testserver.NewSession("ses_existing", "Existing session")
+
+	for _, tt := range []struct {
+		name       string
+		resume     bool
+		reply      testserver.ChatHandler
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "new session",
+			reply:      testserver.Reply("Answer"),
+			wantStdout: "Answer\n",
+		},
+		{
+			name:   "resumed session",
+			resume: true,
+			// the earlier reply is published again before the new one
+			reply: func(s *testserver.Server, sessionID string, prompt string) {
+				s.AddMessage(s.Messages(sessionID)[1])
+				testserver.Reply("Answer")(s, sessionID, prompt)
+			},
+			wantStdout: "Answer\n",
+		},
+		{
+			name: "failed reply",
+			reply: func(s *testserver.Server, sessionID string, prompt string) {
+				s.AddMessage(testserver.FailedMessage("msg_reply", sessionID, "UnknownError", "boom"))
+			},
+			wantCode:   1,
+			wantStdout: "Let me think.\n",
+			wantStderr: "error: boom\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := testserver.New(t)
+			server.OnChat = tt.reply
+			server.AddSession(existing)
+			server.AddMessage(testserver.UserMessage("msg_1", existing.ID, "Earlier question"))
+			server.AddMessage(testserver.AssistantMessage("msg_2", existing.ID, "Earlier answer", true))
+			app_ := newTestApp(t, server)
+			if tt.resume {
+				session := existing
+				app_.Session = &session
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var stdout, stderr bytes.Buffer
+			code := make(chan int, 1)
+			go func() { code <- runHeadless(ctx, app_, "Question", &stdout, &stderr) }()
+			select {
+			case got := <-code:
+				if got != tt.wantCode {
+					t.Errorf("exit code %d, want %d", got, tt.wantCode)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the reply")
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout is %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr is %q, want %q", stderr.String(), tt.wantStderr)
+			}
+			if !tt.resume && app_.Session.ID == existing.ID {
+				t.Error("prompt went to the existing session instead of a new one")
+			}
+		})
+	}
+}
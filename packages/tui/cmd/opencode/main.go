@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -12,6 +14,7 @@ import (
 	"github.com/sst/opencode-sdk-go"
 	"github.com/sst/opencode-sdk-go/option"
 	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/recorder"
 	"github.com/sst/opencode/internal/tui"
 )
 
@@ -23,11 +26,38 @@ func main() {
 		version = "v" + Version
 	}
 
+	printMode := flag.Bool("print", false, "send a prompt non-interactively, print the response and exit")
+	sessionID := flag.String("session", "", "open the session with the given id")
+	continueLast := flag.Bool("continue", false, "continue the most recent session")
+	model := flag.String("model", "", "model to use, in the form provider/model")
+	prompt := flag.String("prompt", "", "prompt to send on start")
+	recordPath := flag.String("record", "", "record server traffic and events to the given file")
+	replayPath := flag.String("replay", "", "replay a recording instead of connecting to a server")
+	replaySpeed := flag.Float64("replay-speed", 1, "speed multiplier for --replay")
+	flag.Parse()
+
 	url := os.Getenv("OPENCODE_SERVER")
 
+	var recording *recorder.Recording
+	if *replayPath != "" {
+		if *replaySpeed <= 0 {
+			fmt.Fprintln(os.Stderr, "error: --replay-speed must be positive")
+			os.Exit(1)
+		}
+		var err error
+		recording, err = recorder.Load(*replayPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error: failed to load recording:", err)
+			os.Exit(1)
+		}
+	}
+
 	appInfoStr := os.Getenv("OPENCODE_APP_INFO")
 	var appInfo opencode.App
 	err := json.Unmarshal([]byte(appInfoStr), &appInfo)
+	if err != nil && recording != nil && recording.App != nil {
+		appInfo, err = recording.LocalApp()
+	}
 	if err != nil {
 		slog.Error("Failed to unmarshal app info", "error", err)
 		os.Exit(1)
@@ -52,9 +82,23 @@ func main() {
 
 	slog.Debug("TUI launched", "app", appInfo)
 
-	httpClient := opencode.NewClient(
-		option.WithBaseURL(url),
-	)
+	clientOptions := []option.RequestOption{option.WithBaseURL(url)}
+	switch {
+	case recording != nil:
+		clientOptions = []option.RequestOption{
+			option.WithBaseURL("http://replay.invalid"),
+			option.WithHTTPClient(recording.Client()),
+		}
+	case *recordPath != "":
+		rec, err := recorder.New(*recordPath, appInfo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error: failed to create recording:", err)
+			os.Exit(1)
+		}
+		defer rec.Close()
+		clientOptions = append(clientOptions, option.WithMiddleware(rec.Middleware))
+	}
+	httpClient := opencode.NewClient(clientOptions...)
 
 	if err != nil {
 		slog.Error("Failed to create client", "error", err)
@@ -70,24 +114,57 @@ func main() {
 		panic(err)
 	}
 
+	if *model != "" {
+		if err := app_.OverrideModel(ctx, *model); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+	}
+
+	if *sessionID != "" || *continueLast {
+		session, err := findSession(ctx, app_, *sessionID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		app_.Session = session
+	}
+
+	if recording != nil && app_.Session.ID == "" {
+		if session, ok := recording.Session(); ok {
+			app_.Session = &session
+		}
+	}
+
+	if *printMode {
+		if *prompt == "" {
+			*prompt, err = readPrompt(flag.Args())
+			if err != nil {
+				slog.Error("Failed to read prompt", "error", err)
+				os.Exit(1)
+			}
+		}
+		if *prompt == "" {
+			fmt.Fprintln(os.Stderr, "error: no prompt given, pass it as arguments, with --prompt or on stdin")
+			os.Exit(1)
+		}
+		code := runHeadless(ctx, app_, *prompt, os.Stdout, os.Stderr)
+		file.Close()
+		cancel()
+		os.Exit(code)
+	}
+
 	program := tea.NewProgram(
-		tui.NewModel(app_),
+		tui.NewModel(app_, tui.WithInitialPrompt(*prompt)),
 		tea.WithAltScreen(),
 		tea.WithKeyboardEnhancements(),
 		tea.WithMouseCellMotion(),
 	)
 
-	go func() {
-		stream := httpClient.Event.ListStreaming(ctx)
-		for stream.Next() {
-			evt := stream.Current().AsUnion()
-			program.Send(evt)
-		}
-		if err := stream.Err(); err != nil {
-			slog.Error("Error streaming events", "error", err)
-			program.Send(err)
-		}
-	}()
+	go app_.StreamEvents(ctx, program.Send)
+	if recording != nil {
+		go recording.Play(ctx, program.Send, *replaySpeed)
+	}
 
 	// Run the TUI
 	result, err := program.Run()
@@ -97,3 +174,30 @@ func main() {
 
 	slog.Info("TUI exited", "result", result)
 }
+
+// findSession looks up the session with the given id, or the most recently
+// updated top-level session when id is empty.
+func findSession(ctx context.Context, app_ *app.App, id string) (*opencode.Session, error) {
+	sessions, err := app_.ListSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var latest *opencode.Session
+	for _, session := range sessions {
+		if id != "" && session.ID == id {
+			return &session, nil
+		}
+		if id == "" && session.ParentID == "" &&
+			(latest == nil || session.Time.Updated > latest.Time.Updated) {
+			latest = &session
+		}
+	}
+	if id != "" {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no session to continue")
+	}
+	return latest, nil
+}
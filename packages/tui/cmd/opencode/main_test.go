@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func session(t *testing.T, id, parentID string, updated int) opencode.Session {
+	t.Helper()
+	var session opencode.Session
+	data := fmt.Sprintf(`{"id": %q, "title": %q, "version": "test", "time": {"created": 1, "updated": %d}}`, id, id, updated)
+	if parentID != "" {
+		data = fmt.Sprintf(`{"id": %q, "parentID": %q, "title": %q, "version": "test", "time": {"created": 1, "updated": %d}}`,
+			id, parentID, id, updated)
+	}
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestFindSession(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		sessions []opencode.Session
+		id       string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "by id",
+			sessions: []opencode.Session{session(t, "ses_1", "", 1), session(t, "ses_2", "", 2)},
+			id:       "ses_1",
+			want:     "ses_1",
+		},
+		{
+			name:     "child session by id",
+			sessions: []opencode.Session{session(t, "ses_1", "", 1), session(t, "ses_2", "ses_1", 2)},
+			id:       "ses_2",
+			want:     "ses_2",
+		},
+		{
+			name:     "unknown id",
+			sessions: []opencode.Session{session(t, "ses_1", "", 1)},
+			id:       "ses_9",
+			wantErr:  true,
+		},
+		{
+			name:     "continue the latest",
+			sessions: []opencode.Session{session(t, "ses_1", "", 3), session(t, "ses_2", "", 1)},
+			want:     "ses_1",
+		},
+		{
+			name:     "continue skips child sessions",
+			sessions: []opencode.Session{session(t, "ses_1", "", 1), session(t, "ses_2", "ses_1", 2)},
+			want:     "ses_1",
+		},
+		{
+			name:    "nothing to continue",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := testserver.New(t)
+			for _, session := range tt.sessions {
+				server.AddSession(session)
+			}
+			got, err := findSession(context.Background(), newTestApp(t, server), tt.id)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("found %s, want an error", got.ID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != tt.want {
+				t.Errorf("found %s, want %s", got.ID, tt.want)
+			}
+		})
+	}
+}
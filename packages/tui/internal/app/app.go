@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"sort"
@@ -21,17 +22,33 @@ import (
 )
 
 type App struct {
-	Info      opencode.App
-	Version   string
-	StatePath string
-	Config    *opencode.Config
-	Client    *opencode.Client
-	State     *config.State
-	Provider  *opencode.Provider
-	Model     *opencode.Model
-	Session   *opencode.Session
-	Messages  []opencode.Message
-	Commands  commands.CommandRegistry
+	Info            opencode.App
+	Version         string
+	StatePath       string
+	Config          *opencode.Config
+	Client          *opencode.Client
+	State           *config.State
+	Budgets         config.Budgets
+	AutoCompact     int
+	Notifications   config.Notifications
+	Provider        *opencode.Provider
+	Model           *opencode.Model
+	Session         *opencode.Session
+	Messages        []opencode.Message
+	Commands        commands.CommandRegistry
+	Compaction      *Compaction
+	queues          map[string][]QueuedPrompt
+	shells          map[string][]ShellResult
+	tabs            []Tab
+	activeTab       int
+	history         []string
+	notified        map[string]bool
+	budgetWarned    map[string]bool
+	budgetOverrides map[string]bool
+	spend           *dailySpend
+	// modelOverride is the model given on the command line, used for this
+	// run only.
+	modelOverride *ModelSelectedMsg
 }
 
 type SessionSelectedMsg = *opencode.Session
@@ -39,6 +56,9 @@ type SessionLoadedMsg struct{}
 type ModelSelectedMsg struct {
 	Provider opencode.Provider
 	Model    opencode.Model
+	// Override is set for a model given on the command line, which must not
+	// be remembered as the last used one.
+	Override bool
 }
 type SessionClearedMsg struct{}
 type CompactSessionMsg struct{}
@@ -109,34 +129,70 @@ func New(
 		theme.SetTheme(appState.Theme)
 	}
 
+	var budgets config.Budgets
+	decodeConfig(configInfo, "budget", &budgets)
+	var autoCompact int
+	decodeConfig(configInfo, "autocompact", &autoCompact)
+	notifications := config.Notifications{Method: NotifyBell}
+	decodeConfig(configInfo, "notifications", &notifications)
+
 	slog.Debug("Loaded config", "config", configInfo)
 
 	app := &App{
-		Info:      appInfo,
-		Version:   version,
-		StatePath: appStatePath,
-		Config:    configInfo,
-		State:     appState,
-		Client:    httpClient,
-		Session:   &opencode.Session{},
-		Messages:  []opencode.Message{},
-		Commands:  commands.LoadFromConfig(configInfo),
+		Info:          appInfo,
+		Version:       version,
+		StatePath:     appStatePath,
+		Config:        configInfo,
+		State:         appState,
+		Budgets:       budgets,
+		AutoCompact:   autoCompact,
+		Notifications: notifications,
+		Client:        httpClient,
+		Commands:      commands.LoadFromConfig(configInfo),
+		tabs:          []Tab{emptyTab()},
 	}
+	app.Commands.AddCustom(commands.LoadCustomCommands(
+		filepath.Join(appInfo.Path.Config, "commands"),
+		filepath.Join(appInfo.Path.Root, ".opencode", "commands"),
+		filepath.Join(appInfo.Path.Cwd, ".opencode", "commands"),
+	))
+	app.activateTab(0)
+	app.loadHistory()
 
 	return app, nil
 }
 
+// decodeConfig decodes the key of the opencode config into v, for settings
+// of the TUI the SDK has no field for yet.
+func decodeConfig(configInfo *opencode.Config, key string, v any) {
+	field, ok := configInfo.JSON.ExtraFields[key]
+	if !ok || field.IsNull() {
+		return
+	}
+	if err := json.Unmarshal([]byte(field.Raw()), v); err != nil {
+		slog.Warn("Failed to decode config", "key", key, "error", err)
+	}
+}
+
 func (a *App) Key(commandName commands.CommandName) string {
 	t := theme.CurrentTheme()
 	base := styles.NewStyle().Background(t.Background()).Foreground(t.Text()).Bold(true).Render
 	muted := styles.NewStyle().Background(t.Background()).Foreground(t.TextMuted()).Faint(true).Render
+	return base(a.KeyBinding(commandName)) + muted(" "+a.Commands[commandName].Description)
+}
+
+// KeyBinding returns the first key bound to a command, prefixed with the
+// leader key when it needs one, or "" when the command has no binding.
+func (a *App) KeyBinding(commandName commands.CommandName) string {
 	command := a.Commands[commandName]
+	if len(command.Keybindings) == 0 {
+		return ""
+	}
 	kb := command.Keybindings[0]
-	key := kb.Key
 	if kb.RequiresLeader {
-		key = a.Config.Keybinds.Leader + " " + kb.Key
+		return a.Config.Keybinds.Leader + " " + kb.Key
 	}
-	return base(key) + muted(" "+command.Description)
+	return kb.Key
 }
 
 func (a *App) InitializeProvider() tea.Cmd {
@@ -176,6 +232,10 @@ func (a *App) InitializeProvider() tea.Cmd {
 			return nil
 		}
 
+		if a.modelOverride != nil {
+			return *a.modelOverride
+		}
+
 		var currentProvider *opencode.Provider
 		var currentModel *opencode.Model
 		for _, provider := range providers {
@@ -201,6 +261,31 @@ func (a *App) InitializeProvider() tea.Cmd {
 	}
 }
 
+// OverrideModel makes InitializeProvider select the given model, written as
+// provider/model, instead of the last used one, without saving it.
+func (a *App) OverrideModel(ctx context.Context, model string) error {
+	providerID, modelID, ok := strings.Cut(model, "/")
+	if !ok || providerID == "" || modelID == "" {
+		return fmt.Errorf("invalid model %q, expected provider/model", model)
+	}
+	response, err := a.Client.Config.Providers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list providers: %w", err)
+	}
+	for _, provider := range response.Providers {
+		if provider.ID != providerID {
+			continue
+		}
+		found, ok := provider.Models[modelID]
+		if !ok {
+			return fmt.Errorf("unknown model %q for provider %q", modelID, providerID)
+		}
+		a.modelOverride = &ModelSelectedMsg{Provider: provider, Model: found, Override: true}
+		return nil
+	}
+	return fmt.Errorf("unknown provider %q", providerID)
+}
+
 func getDefaultModel(response *opencode.ConfigProvidersResponse, provider opencode.Provider) *opencode.Model {
 	if match, ok := response.Default[provider.ID]; ok {
 		model := provider.Models[match]
@@ -221,14 +306,30 @@ type Attachment struct {
 }
 
 func (a *App) IsBusy() bool {
-	if len(a.Messages) == 0 {
+	return isBusy(a.Messages)
+}
+
+func isBusy(messages []opencode.Message) bool {
+	if len(messages) == 0 {
 		return false
 	}
 
-	lastMessage := a.Messages[len(a.Messages)-1]
+	lastMessage := messages[len(messages)-1]
 	return lastMessage.Metadata.Time.Completed == 0
 }
 
+// ContextUsage returns the tokens in the context of the current session, as
+// of its last assistant message, and the total cost so far.
+func (a *App) ContextUsage() (tokens float64, cost float64) {
+	for _, message := range a.Messages {
+		cost += message.Metadata.Assistant.Cost
+		if size, ok := contextTokens(message); ok {
+			tokens = size
+		}
+	}
+	return tokens, cost
+}
+
 func (a *App) SaveState() {
 	err := config.SaveState(a.StatePath, a.State)
 	if err != nil {
@@ -262,19 +363,6 @@ func (a *App) InitializeProject(ctx context.Context) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
-func (a *App) CompactSession(ctx context.Context) tea.Cmd {
-	go func() {
-		_, err := a.Client.Session.Summarize(ctx, a.Session.ID, opencode.SessionSummarizeParams{
-			ProviderID: opencode.F(a.Provider.ID),
-			ModelID:    opencode.F(a.Model.ID),
-		})
-		if err != nil {
-			slog.Error("Failed to compact session", "error", err)
-		}
-	}()
-	return nil
-}
-
 func (a *App) MarkProjectInitialized(ctx context.Context) error {
 	_, err := a.Client.App.Init(ctx)
 	if err != nil {
@@ -293,6 +381,28 @@ func (a *App) CreateSession(ctx context.Context) (*opencode.Session, error) {
 }
 
 func (a *App) SendChatMessage(ctx context.Context, text string, attachments []Attachment) tea.Cmd {
+	if a.Provider == nil || a.Model == nil {
+		return toast.NewErrorToast("No model selected")
+	}
+	if a.IsCompacting() {
+		return toast.NewWarningToast("Wait for the session to finish compacting")
+	}
+	if exceeded, ok := a.exceededBudget(); ok {
+		exceeded.Text, exceeded.Attachments = text, attachments
+		return util.CmdHandler(exceeded)
+	}
+
+	loaded := make([]Attachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		attachment, err := attachment.load()
+		if err != nil {
+			slog.Error("Failed to read attachment", "path", attachment.FilePath, "error", err)
+			return toast.NewErrorToast("Failed to read attachment " + attachment.FileName)
+		}
+		loaded = append(loaded, attachment)
+	}
+	attachments = loaded
+
 	var cmds []tea.Cmd
 	if a.Session.ID == "" {
 		session, err := a.CreateSession(ctx)
@@ -303,17 +413,19 @@ func (a *App) SendChatMessage(ctx context.Context, text string, attachments []At
 		cmds = append(cmds, util.CmdHandler(SessionSelectedMsg(session)))
 	}
 
+	// the server drops reverted messages when the next prompt comes in
+	if _, ok := a.RevertPoint(); ok {
+		a.Messages = a.VisibleMessages()
+	}
+
 	optimisticMessage := opencode.Message{
-		ID:   fmt.Sprintf("optimistic-%d", time.Now().UnixNano()),
-		Role: opencode.MessageRoleUser,
-		Parts: []opencode.MessagePart{{
-			Type: opencode.MessagePartTypeText,
-			Text: text,
-		}},
+		ID:    fmt.Sprintf("optimistic-%d", time.Now().UnixNano()),
+		Role:  opencode.MessageRoleUser,
+		Parts: messageParts(text, attachments),
 		Metadata: opencode.MessageMetadata{
 			SessionID: a.Session.ID,
 			Time: opencode.MessageMetadataTime{
-				Created: float64(time.Now().Unix()),
+				Created: float64(time.Now().UnixMilli()),
 			},
 		},
 	}
@@ -321,16 +433,24 @@ func (a *App) SendChatMessage(ctx context.Context, text string, attachments []At
 	a.Messages = append(a.Messages, optimisticMessage)
 	cmds = append(cmds, util.CmdHandler(OptimisticMessageAddedMsg{Message: optimisticMessage}))
 
+	parts := []opencode.MessagePartUnionParam{
+		opencode.TextPartParam{
+			Type: opencode.F(opencode.TextPartTypeText),
+			Text: opencode.F(text),
+		},
+	}
+	for _, attachment := range attachments {
+		parts = append(parts, attachment.part())
+	}
+
+	sessionID := a.Session.ID
+	providerID := a.Provider.ID
+	modelID := a.Model.ID
 	cmds = append(cmds, func() tea.Msg {
-		_, err := a.Client.Session.Chat(ctx, a.Session.ID, opencode.SessionChatParams{
-			Parts: opencode.F([]opencode.MessagePartUnionParam{
-				opencode.TextPartParam{
-					Type: opencode.F(opencode.TextPartTypeText),
-					Text: opencode.F(text),
-				},
-			}),
-			ProviderID: opencode.F(a.Provider.ID),
-			ModelID:    opencode.F(a.Model.ID),
+		_, err := a.Client.Session.Chat(ctx, sessionID, opencode.SessionChatParams{
+			Parts:      opencode.F(parts),
+			ProviderID: opencode.F(providerID),
+			ModelID:    opencode.F(modelID),
 		})
 		if err != nil {
 			errormsg := fmt.Sprintf("failed to send message: %v", err)
@@ -376,6 +496,7 @@ func (a *App) DeleteSession(ctx context.Context, sessionID string) error {
 		slog.Error("Failed to delete session", "error", err)
 		return err
 	}
+	a.SaveDraft(sessionID, Draft{})
 	return nil
 }
 
@@ -404,6 +525,51 @@ func (a *App) ListProviders(ctx context.Context) ([]opencode.Provider, error) {
 	return providers.Providers, nil
 }
 
+// FindModel looks a model up by "provider/model", or by its ID alone when
+// only one provider has it.
+func (a *App) FindModel(ctx context.Context, name string) (opencode.Provider, opencode.Model, bool) {
+	providers, err := a.ListProviders(ctx)
+	if err != nil {
+		return opencode.Provider{}, opencode.Model{}, false
+	}
+	if providerID, modelID, ok := strings.Cut(name, "/"); ok {
+		for _, provider := range providers {
+			if model, ok := provider.Models[modelID]; ok && provider.ID == providerID {
+				return provider, model, true
+			}
+		}
+	}
+	var provider opencode.Provider
+	var model opencode.Model
+	found := 0
+	for _, p := range providers {
+		if m, ok := p.Models[name]; ok {
+			provider, model = p, m
+			found++
+		}
+	}
+	return provider, model, found == 1
+}
+
+// ModelFoundMsg reports the result of LookupModel.
+type ModelFoundMsg struct {
+	Name     string
+	Provider opencode.Provider
+	Model    opencode.Model
+	Found    bool
+	// Retry retries the last prompt once the model is selected.
+	Retry bool
+}
+
+// LookupModel runs FindModel in the background, so the providers aren't
+// fetched while the UI is updating.
+func (a *App) LookupModel(ctx context.Context, name string, retry bool) tea.Cmd {
+	return func() tea.Msg {
+		provider, model, ok := a.FindModel(ctx, name)
+		return ModelFoundMsg{Name: name, Provider: provider, Model: model, Found: ok, Retry: retry}
+	}
+}
+
 // func (a *App) loadCustomKeybinds() {
 //
 // }
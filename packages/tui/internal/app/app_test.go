@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sst/opencode/internal/testserver"
+)
+
+// newTestApp creates an app talking to server, keeping its state in a
+// temporary directory, with the server's default model selected.
+func newTestApp(t *testing.T, server *testserver.Server) *App {
+	t.Helper()
+	app, err := New(context.Background(), "test", testserver.AppIn(t.TempDir()), server.Client())
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	selected, ok := app.InitializeProvider()().(ModelSelectedMsg)
+	if !ok {
+		t.Fatal("no model to select")
+	}
+	app.Provider = &selected.Provider
+	app.Model = &selected.Model
+	return app
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// load fills in the content, name and mime type of an attachment that only
+// refers to a file on disk.
+func (a Attachment) load() (Attachment, error) {
+	if a.Content == nil {
+		content, err := os.ReadFile(a.FilePath)
+		if err != nil {
+			return a, err
+		}
+		a.Content = content
+	}
+	if a.FileName == "" {
+		a.FileName = filepath.Base(a.FilePath)
+	}
+	if a.MimeType == "" {
+		a.MimeType = mime.TypeByExtension(filepath.Ext(a.FilePath))
+	}
+	if a.MimeType == "" {
+		a.MimeType = http.DetectContentType(a.Content)
+	}
+	if mediaType, _, err := mime.ParseMediaType(a.MimeType); err == nil {
+		a.MimeType = mediaType
+	}
+	return a, nil
+}
+
+func (a Attachment) dataURL() string {
+	return "data:" + a.MimeType + ";base64," + base64.StdEncoding.EncodeToString(a.Content)
+}
+
+func (a Attachment) part() opencode.FilePartParam {
+	return opencode.FilePartParam{
+		Type:      opencode.F(opencode.FilePartTypeFile),
+		MediaType: opencode.F(a.MimeType),
+		Filename:  opencode.F(a.FileName),
+		URL:       opencode.F(a.dataURL()),
+	}
+}
+
+// messageParts builds the parts of a user message the way the server
+// returns them. They are decoded from JSON since parts constructed directly
+// have no union and don't render.
+func messageParts(text string, attachments []Attachment) []opencode.MessagePart {
+	raw := []map[string]string{{"type": "text", "text": text}}
+	for _, attachment := range attachments {
+		raw = append(raw, map[string]string{
+			"type":      "file",
+			"mediaType": attachment.MimeType,
+			"filename":  attachment.FileName,
+			"url":       attachment.dataURL(),
+		})
+	}
+	data, _ := json.Marshal(raw)
+	var parts []opencode.MessagePart
+	json.Unmarshal(data, &parts)
+	return parts
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/config"
+)
+
+// BudgetExceededMsg holds back a prompt sent after a budget ran out, until
+// the user confirms sending it or raises the limit.
+type BudgetExceededMsg struct {
+	Budget      string
+	Spent       float64
+	Limit       float64
+	Text        string
+	Attachments []Attachment
+}
+
+// budget is a configured budget together with what was spent against it.
+type budget struct {
+	name  string
+	key   string
+	spent float64
+	config.Budget
+}
+
+// dailySpend is the cost of the messages answered today, in any session or
+// TUI, by message ID.
+type dailySpend struct {
+	Day      string             `json:"day"`
+	Messages map[string]float64 `json:"messages"`
+}
+
+func today() string {
+	return time.Now().Format(time.DateOnly)
+}
+
+// spendPath is where the spend of the day is tracked next to the TUI state.
+func (a *App) spendPath() string {
+	return filepath.Join(filepath.Dir(a.StatePath), "spend.json")
+}
+
+// readSpend reads the spend of the day saved by this or another TUI.
+func (a *App) readSpend() *dailySpend {
+	spend := &dailySpend{Day: today(), Messages: map[string]float64{}}
+	data, err := os.ReadFile(a.spendPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read daily spend", "error", err)
+		}
+		return spend
+	}
+	var saved dailySpend
+	if err := json.Unmarshal(data, &saved); err != nil {
+		slog.Warn("Failed to decode daily spend", "error", err)
+		return spend
+	}
+	if saved.Day == spend.Day && saved.Messages != nil {
+		spend.Messages = saved.Messages
+	}
+	return spend
+}
+
+// dailySpend returns the spend of the day, read from disk the first time and
+// again once the day is over.
+func (a *App) dailySpend() *dailySpend {
+	if a.spend == nil || a.spend.Day != today() {
+		a.spend = a.readSpend()
+	}
+	return a.spend
+}
+
+// RecordSpend counts the cost of an assistant message towards the spend of
+// the day. The spend is saved only when the cost of the message changed,
+// merged with what other TUIs saved in the meantime.
+func (a *App) RecordSpend(message opencode.Message) {
+	cost := message.Metadata.Assistant.Cost
+	if message.Role != opencode.MessageRoleAssistant || cost <= 0 {
+		return
+	}
+	if a.dailySpend().Messages[message.ID] == cost {
+		return
+	}
+	a.spend = a.readSpend()
+	a.spend.Messages[message.ID] = cost
+
+	path := a.spendPath()
+	data, err := json.Marshal(a.spend)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		slog.Error("Failed to save daily spend", "error", err)
+	}
+}
+
+// DailySpend returns how much was spent today across sessions.
+func (a *App) DailySpend() float64 {
+	total := 0.0
+	for _, cost := range a.dailySpend().Messages {
+		total += cost
+	}
+	return total
+}
+
+func (a *App) budgets() []budget {
+	_, cost := a.ContextUsage()
+	return []budget{
+		{name: "Session", key: "session:" + a.Session.ID, spent: cost, Budget: a.Budgets.Session},
+		{name: "Daily", key: "daily:" + today(), spent: a.DailySpend(), Budget: a.Budgets.Daily},
+	}
+}
+
+// BudgetWarning returns a warning the first time the current session or the
+// day passes the warning threshold of its budget.
+func (a *App) BudgetWarning() (string, bool) {
+	if a.budgetWarned == nil {
+		a.budgetWarned = make(map[string]bool)
+	}
+	for _, b := range a.budgets() {
+		if b.Warn <= 0 || b.spent < b.Warn || a.budgetWarned[b.key] {
+			continue
+		}
+		a.budgetWarned[b.key] = true
+		if b.Limit > 0 {
+			return fmt.Sprintf("%s spend is $%.2f of the $%.2f budget", b.name, b.spent, b.Limit), true
+		}
+		return fmt.Sprintf("%s spend is $%.2f", b.name, b.spent), true
+	}
+	return "", false
+}
+
+// exceededBudget returns the first budget whose limit was reached, unless
+// the user already chose to go past it.
+func (a *App) exceededBudget() (BudgetExceededMsg, bool) {
+	for _, b := range a.budgets() {
+		if b.Limit <= 0 || b.spent < b.Limit || a.budgetOverrides[b.key] {
+			continue
+		}
+		return BudgetExceededMsg{Budget: b.name, Spent: b.spent, Limit: b.Limit}, true
+	}
+	return BudgetExceededMsg{}, false
+}
+
+// OverrideBudget lets prompts through past the limit of an exceeded budget,
+// for the rest of the session or the day.
+func (a *App) OverrideBudget(exceeded BudgetExceededMsg) {
+	if a.budgetOverrides == nil {
+		a.budgetOverrides = make(map[string]bool)
+	}
+	for _, b := range a.budgets() {
+		if b.name == exceeded.Budget {
+			a.budgetOverrides[b.key] = true
+		}
+	}
+}
+
+// RaiseBudget doubles the limit of an exceeded budget for the rest of the
+// run. The configured limit is left alone.
+func (a *App) RaiseBudget(exceeded BudgetExceededMsg) float64 {
+	limit := &a.Budgets.Session.Limit
+	if exceeded.Budget == "Daily" {
+		limit = &a.Budgets.Daily.Limit
+	}
+	*limit = max(*limit*2, exceeded.Spent)
+	return *limit
+}
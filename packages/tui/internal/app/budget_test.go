@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/config"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestBudgetConfig(t *testing.T) {
+	server := testserver.New(t)
+	server.Configure(`{"keybinds": {"leader": "ctrl+x"}, "budget": {"session": {"warn": 1, "limit": 2}, "daily": {"limit": 10}}}`)
+	app := newTestApp(t, server)
+	want := config.Budgets{Session: config.Budget{Warn: 1, Limit: 2}, Daily: config.Budget{Limit: 10}}
+	if app.Budgets != want {
+		t.Errorf("budgets are %+v, want %+v", app.Budgets, want)
+	}
+}
+
+func TestSessionBudget(t *testing.T) {
+	server := testserver.New(t)
+	session := testserver.NewSession("ses_1", "Session")
+	server.AddSession(session)
+	app := newTestApp(t, server)
+	app.Budgets.Session = config.Budget{Warn: 0.01, Limit: 0.02}
+	app.Session = &session
+
+	// each reply costs $0.01
+	reply := func(id string) {
+		app.Messages = append(app.Messages, testserver.AssistantMessage(id, session.ID, "Reply", true))
+	}
+	if warning, ok := app.BudgetWarning(); ok {
+		t.Fatalf("warned before spending anything: %s", warning)
+	}
+	reply("msg_1")
+	if warning, ok := app.BudgetWarning(); !ok || warning != "Session spend is $0.01 of the $0.02 budget" {
+		t.Errorf("warning is %q, want one at the soft limit", warning)
+	}
+	if _, ok := app.BudgetWarning(); ok {
+		t.Error("warned twice for the same budget")
+	}
+
+	reply("msg_2")
+	exceeded, ok := app.SendChatMessage(context.Background(), "One more", nil)().(BudgetExceededMsg)
+	if !ok || exceeded.Budget != "Session" || exceeded.Text != "One more" {
+		t.Fatalf("prompt past the limit was not held back: %+v", exceeded)
+	}
+	if got := len(server.Messages(session.ID)); got != 0 {
+		t.Fatalf("prompt past the limit reached the server")
+	}
+
+	if limit := app.RaiseBudget(exceeded); limit != 0.04 {
+		t.Errorf("raised the limit to %v, want 0.04", limit)
+	}
+	if _, ok := app.exceededBudget(); ok {
+		t.Error("budget is still exceeded after raising it")
+	}
+	reply("msg_3")
+	reply("msg_4")
+	exceeded, ok = app.exceededBudget()
+	if !ok {
+		t.Fatal("raised budget is not enforced")
+	}
+	app.OverrideBudget(exceeded)
+	if _, ok := app.exceededBudget(); ok {
+		t.Error("budget is still enforced after going past it")
+	}
+}
+
+func TestDailySpend(t *testing.T) {
+	server := testserver.New(t)
+	app := newTestApp(t, server)
+	// another TUI of the same user shares the spend
+	other, err := New(context.Background(), "test", testserver.AppIn(app.Info.Path.State), server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.RecordSpend(testserver.AssistantMessage("msg_1", "ses_1", "Reply", true))
+	app.RecordSpend(testserver.AssistantMessage("msg_1", "ses_1", "Reply", true))
+	other.RecordSpend(testserver.AssistantMessage("msg_2", "ses_2", "Reply", true))
+	other.RecordSpend(testserver.UserMessage("msg_3", "ses_2", "Prompt"))
+	app.RecordSpend(testserver.AssistantMessage("msg_4", "ses_1", "Reply", true))
+
+	if spent := app.DailySpend(); math.Abs(spent-0.03) > 1e-9 {
+		t.Errorf("daily spend is %v, want 0.03 across both TUIs", spent)
+	}
+
+	app.Budgets.Daily = config.Budget{Limit: 0.03}
+	app.Session = &opencode.Session{ID: "ses_3"}
+	if exceeded, ok := app.exceededBudget(); !ok || exceeded.Budget != "Daily" {
+		t.Errorf("daily budget is not exceeded: %+v", exceeded)
+	}
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/components/toast"
+)
+
+// Compaction is a summarize request in flight. The session is locked on the
+// server until the summary message has been written.
+type Compaction struct {
+	SessionID string
+	Started   time.Time
+}
+
+// CompactionFinishedMsg is sent when the server has finished summarizing a
+// session, successfully or not.
+type CompactionFinishedMsg struct {
+	SessionID string
+	Err       error
+}
+
+// IsCompacting reports whether the current session is being summarized.
+func (a *App) IsCompacting() bool {
+	return a.Compaction != nil && a.Compaction.SessionID == a.Session.ID
+}
+
+// CompactSession summarizes the current session, blocking new prompts until
+// the server is done.
+func (a *App) CompactSession(ctx context.Context) tea.Cmd {
+	if a.Compaction != nil {
+		return toast.NewWarningToast("Compaction is already running")
+	}
+	if a.Provider == nil || a.Model == nil {
+		return nil
+	}
+	a.Compaction = &Compaction{SessionID: a.Session.ID, Started: time.Now()}
+	sessionID := a.Session.ID
+	providerID := a.Provider.ID
+	modelID := a.Model.ID
+	return func() tea.Msg {
+		_, err := a.Client.Session.Summarize(ctx, sessionID, opencode.SessionSummarizeParams{
+			ProviderID: opencode.F(providerID),
+			ModelID:    opencode.F(modelID),
+		})
+		if err != nil {
+			slog.Error("Failed to compact session", "error", err)
+		}
+		return CompactionFinishedMsg{SessionID: sessionID, Err: err}
+	}
+}
+
+// ShouldAutoCompact reports whether the context of the current session has
+// grown past the configured share of the model's context window.
+func (a *App) ShouldAutoCompact() bool {
+	threshold := a.AutoCompact
+	if threshold <= 0 || a.Compaction != nil || a.Session.ID == "" || a.IsBusy() {
+		return false
+	}
+	if a.Model == nil || a.Model.Limit.Context <= 0 {
+		return false
+	}
+	tokens, _ := a.ContextUsage()
+	return tokens/a.Model.Limit.Context*100 >= float64(threshold)
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestShouldAutoCompact(t *testing.T) {
+	server := testserver.New(t)
+	server.Configure(`{"keybinds": {"leader": "ctrl+x"}, "autocompact": 50}`)
+	app := newTestApp(t, server)
+	if app.AutoCompact != 50 {
+		t.Fatalf("autocompact is %d, want 50 from the config", app.AutoCompact)
+	}
+	session := testserver.NewSession("ses_1", "Session")
+	app.Session = &session
+	// the reply leaves 120 tokens in the context
+	app.Messages = []opencode.Message{
+		testserver.UserMessage("msg_1", session.ID, "Hello"),
+		testserver.AssistantMessage("msg_2", session.ID, "Hi", true),
+	}
+
+	for _, tt := range []struct {
+		name        string
+		context     float64
+		autoCompact int
+		busy        bool
+		compacting  bool
+		want        bool
+	}{
+		{name: "past the threshold", context: 200, autoCompact: 50, want: true},
+		{name: "below the threshold", context: 1000, autoCompact: 50},
+		{name: "disabled", context: 200},
+		{name: "busy", context: 200, autoCompact: 50, busy: true},
+		{name: "already compacting", context: 200, autoCompact: 50, compacting: true},
+		{name: "unknown context window", autoCompact: 50},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app.AutoCompact = tt.autoCompact
+			app.Model = &opencode.Model{Limit: opencode.ModelLimit{Context: tt.context}}
+			app.Compaction = nil
+			if tt.compacting {
+				app.Compaction = &Compaction{SessionID: session.ID}
+			}
+			messages := app.Messages
+			if tt.busy {
+				app.Messages = append(messages, testserver.AssistantMessage("msg_3", session.ID, "", false))
+			}
+			defer func() { app.Messages = messages }()
+
+			if got := app.ShouldAutoCompact(); got != tt.want {
+				t.Errorf("ShouldAutoCompact() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
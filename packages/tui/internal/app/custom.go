@@ -0,0 +1,35 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/sst/opencode/internal/commands"
+)
+
+var fileReferenceRE = regexp.MustCompile(`(?:^|\s)@([^\s]+)`)
+
+// CustomCommandPrompt expands a custom command into the prompt it sends.
+// Files the prompt refers to as @path, relative to the project root, are
+// attached.
+func (a *App) CustomCommandPrompt(command commands.Command, arguments string) SendMsg {
+	text := command.Expand(arguments)
+	var attachments []Attachment
+	seen := map[string]bool{}
+	for _, match := range fileReferenceRE.FindAllStringSubmatch(text, -1) {
+		path := match[1]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(a.Info.Path.Root, path)
+		}
+		if seen[path] {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		seen[path] = true
+		attachments = append(attachments, Attachment{FilePath: path})
+	}
+	return SendMsg{Text: text, Attachments: attachments}
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Draft is a prompt being written, kept so that it survives a restart.
+type Draft struct {
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// draftPath is where the draft of a session is kept, sessionID being empty
+// for a prompt written before a session exists.
+func (a *App) draftPath(sessionID string) string {
+	name := sessionID
+	if name == "" {
+		name = "new"
+	}
+	return filepath.Join(filepath.Dir(a.StatePath), "drafts", a.projectKey(), name+".json")
+}
+
+// LoadDraft returns the draft left in a session, if any.
+func (a *App) LoadDraft(sessionID string) (Draft, bool) {
+	var draft Draft
+	data, err := os.ReadFile(a.draftPath(sessionID))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read draft", "error", err)
+		}
+		return draft, false
+	}
+	if err := json.Unmarshal(data, &draft); err != nil {
+		slog.Warn("Failed to decode draft", "error", err)
+		return draft, false
+	}
+	return draft, true
+}
+
+// SaveDraft keeps the prompt being written in a session, or forgets the
+// draft once the prompt is empty.
+func (a *App) SaveDraft(sessionID string, draft Draft) {
+	path := a.draftPath(sessionID)
+	if draft.Text == "" && len(draft.Attachments) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Error("Failed to remove draft", "error", err)
+		}
+		return
+	}
+
+	data, err := json.Marshal(draft)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		slog.Error("Failed to save draft", "error", err)
+	}
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestDrafts(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	attachment := Attachment{FilePath: "/project/main.go", FileName: "main.go", MimeType: "text/plain"}
+
+	app.SaveDraft("", Draft{Text: "before the session"})
+	app.SaveDraft("ses_1", Draft{Text: "half a prompt", Attachments: []Attachment{attachment}})
+	app.SaveDraft("ses_2", Draft{Attachments: []Attachment{attachment}})
+
+	for _, tt := range []struct {
+		sessionID   string
+		text        string
+		attachments int
+	}{
+		{sessionID: "", text: "before the session"},
+		{sessionID: "ses_1", text: "half a prompt", attachments: 1},
+		{sessionID: "ses_2", attachments: 1},
+	} {
+		draft, ok := app.LoadDraft(tt.sessionID)
+		if !ok || draft.Text != tt.text || len(draft.Attachments) != tt.attachments {
+			t.Errorf("draft of %q is %+v, want %q with %d attachments", tt.sessionID, draft, tt.text, tt.attachments)
+		}
+	}
+	if draft, _ := app.LoadDraft("ses_1"); draft.Attachments[0].FilePath != attachment.FilePath ||
+		draft.Attachments[0].MimeType != attachment.MimeType {
+		t.Errorf("attachment came back as %+v", draft.Attachments[0])
+	}
+
+	// an empty prompt forgets the draft
+	app.SaveDraft("ses_1", Draft{})
+	if draft, ok := app.LoadDraft("ses_1"); ok {
+		t.Errorf("emptied draft is still there: %+v", draft)
+	}
+	if _, ok := app.LoadDraft("ses_3"); ok {
+		t.Error("loaded a draft that was never saved")
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+)
+
+const (
+	minReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
+)
+
+// reconnectAfter waits out the delay before reconnecting, tests replace it
+// to skip the wait.
+var reconnectAfter = time.After
+
+// ConnectionStatusMsg is sent whenever the event stream connects or drops.
+type ConnectionStatusMsg struct {
+	Connected bool
+	Attempt   int
+	Err       error
+}
+
+// ReconnectedMsg is sent after the event stream comes back from a dropped
+// connection, events published in the meantime were missed.
+type ReconnectedMsg struct{}
+
+// SessionResyncedMsg carries freshly fetched state for an open session.
+type SessionResyncedMsg struct {
+	Session  opencode.Session
+	Messages []opencode.Message
+}
+
+// StreamEvents forwards every server event to send, reconnecting with
+// exponential backoff whenever the stream ends, until ctx is cancelled.
+func (a *App) StreamEvents(ctx context.Context, send func(tea.Msg)) {
+	delay := minReconnectDelay
+	attempt := 0
+	connectedOnce := false
+
+	for ctx.Err() == nil {
+		stream := a.Client.Event.ListStreaming(ctx)
+		connected := false
+		for stream.Next() {
+			if !connected {
+				connected = true
+				delay = minReconnectDelay
+				attempt = 0
+				send(ConnectionStatusMsg{Connected: true})
+				if connectedOnce {
+					slog.Info("Event stream reconnected")
+					send(ReconnectedMsg{})
+				}
+				connectedOnce = true
+			}
+			if evt := stream.Current().AsUnion(); evt != nil {
+				send(evt)
+			}
+		}
+		err := stream.Err()
+		stream.Close()
+		if ctx.Err() != nil {
+			return
+		}
+
+		attempt++
+		slog.Error("Event stream disconnected", "error", err, "attempt", attempt, "retryIn", delay)
+		send(ConnectionStatusMsg{Connected: false, Attempt: attempt, Err: err})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-reconnectAfter(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// Resync re-fetches providers and the open sessions with their messages, so
+// that events missed while disconnected don't leave stale state behind.
+func (a *App) Resync(ctx context.Context) tea.Cmd {
+	cmds := []tea.Cmd{a.InitializeProvider()}
+
+	sessionIDs := a.openSessionIDs()
+	if len(sessionIDs) > 0 {
+		cmds = append(cmds, func() tea.Msg {
+			sessions, err := a.ListSessions(ctx)
+			if err != nil {
+				slog.Error("Failed to resync sessions", "error", err)
+				return nil
+			}
+			var resyncs []tea.Cmd
+			for _, sessionID := range sessionIDs {
+				index := slices.IndexFunc(sessions, func(s opencode.Session) bool { return s.ID == sessionID })
+				if index < 0 {
+					slog.Warn("Open session no longer exists", "session", sessionID)
+					continue
+				}
+				session := sessions[index]
+				resyncs = append(resyncs, func() tea.Msg {
+					messages, err := a.ListMessages(ctx, sessionID)
+					if err != nil {
+						slog.Error("Failed to resync messages", "error", err)
+						return nil
+					}
+					return SessionResyncedMsg{Session: session, Messages: messages}
+				})
+			}
+			return tea.BatchMsg(resyncs)
+		})
+	}
+
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+// flatten runs cmd and the batches it returns, collecting their messages.
+func flatten(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case nil:
+		return nil
+	case tea.BatchMsg:
+		var msgs []tea.Msg
+		for _, cmd := range msg {
+			msgs = append(msgs, flatten(cmd)...)
+		}
+		return msgs
+	default:
+		return []tea.Msg{msg}
+	}
+}
+
+func TestStreamEventsReconnects(t *testing.T) {
+	server := testserver.New(t)
+	app := newTestApp(t, server)
+
+	// the first connection drops, the next eight fail and the last one stays
+	// up
+	var mu sync.Mutex
+	connections := 0
+	server.Handle("GET", "/event", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		connections++
+		n := connections
+		mu.Unlock()
+		if n > 1 && n < 10 {
+			http.Error(w, `{"error":"unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {}\n\n")
+		w.(http.Flusher).Flush()
+		if n == 10 {
+			<-r.Context().Done()
+		}
+	})
+
+	var delays []time.Duration
+	reconnectAfter = func(delay time.Duration) <-chan time.Time {
+		delays = append(delays, delay)
+		return time.After(0)
+	}
+	t.Cleanup(func() { reconnectAfter = time.After })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var msgs []tea.Msg
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.StreamEvents(ctx, func(msg tea.Msg) {
+			msgs = append(msgs, msg)
+			if _, ok := msg.(ReconnectedMsg); ok {
+				cancel()
+			}
+		})
+	}()
+	<-done
+
+	var got []string
+	for _, msg := range msgs {
+		switch msg := msg.(type) {
+		case ConnectionStatusMsg:
+			if msg.Connected {
+				got = append(got, "connected")
+			} else {
+				got = append(got, fmt.Sprintf("disconnected %d", msg.Attempt))
+			}
+		case ReconnectedMsg:
+			got = append(got, "reconnected")
+		}
+	}
+	want := []string{"connected"}
+	for attempt := 1; attempt <= 9; attempt++ {
+		want = append(want, fmt.Sprintf("disconnected %d", attempt))
+	}
+	want = append(want, "connected", "reconnected")
+	if !slices.Equal(got, want) {
+		t.Errorf("got messages %v, want %v", got, want)
+	}
+
+	wantDelays := []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second,
+		8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second, 30 * time.Second,
+	}
+	if !slices.Equal(delays, wantDelays) {
+		t.Errorf("waited %v between attempts, want %v", delays, wantDelays)
+	}
+}
+
+func TestResync(t *testing.T) {
+	server := testserver.New(t)
+	app := newTestApp(t, server)
+	kept := testserver.NewSession("ses_kept", "Kept")
+	deleted := testserver.NewSession("ses_deleted", "Deleted")
+	server.AddSession(kept)
+	server.AddMessage(testserver.UserMessage("msg_1", kept.ID, "Hello"))
+	server.AddMessage(testserver.AssistantMessage("msg_2", kept.ID, "Missed reply", true))
+	// both were open before the connection dropped, one of them has been
+	// deleted since
+	app.OpenTab(&kept, nil)
+	app.OpenTab(&deleted, nil)
+
+	var resynced []SessionResyncedMsg
+	for _, msg := range flatten(app.Resync(context.Background())) {
+		if msg, ok := msg.(SessionResyncedMsg); ok {
+			resynced = append(resynced, msg)
+		}
+	}
+	if len(resynced) != 1 || resynced[0].Session.ID != kept.ID {
+		t.Fatalf("resynced %+v, want only %s", resynced, kept.ID)
+	}
+	if messages := resynced[0].Messages; len(messages) != 2 || messages[1].ID != "msg_2" {
+		t.Errorf("resynced messages %+v, want the missed reply", messages)
+	}
+}
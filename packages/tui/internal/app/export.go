@@ -0,0 +1,229 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// ExportFormat is a file format a session can be exported to.
+type ExportFormat string
+
+const (
+	ExportMarkdown ExportFormat = "markdown"
+	ExportJSON     ExportFormat = "json"
+	ExportHTML     ExportFormat = "html"
+)
+
+// ExportFormats lists the formats in the order they are offered.
+var ExportFormats = []ExportFormat{ExportMarkdown, ExportJSON, ExportHTML}
+
+// Extension is the file extension for the format, with its dot.
+func (f ExportFormat) Extension() string {
+	switch f {
+	case ExportJSON:
+		return ".json"
+	case ExportHTML:
+		return ".html"
+	default:
+		return ".md"
+	}
+}
+
+// ParseExportFormat reads a format name, also accepting the extension.
+func ParseExportFormat(name string) (ExportFormat, bool) {
+	name = strings.TrimPrefix(strings.ToLower(name), ".")
+	for _, format := range ExportFormats {
+		if name == string(format) || "."+name == format.Extension() {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+var nonSlugRE = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ExportPath is where the current session is exported to unless another
+// path is chosen: a file named after the session in the project root.
+func (a *App) ExportPath(format ExportFormat) string {
+	name := strings.Trim(nonSlugRE.ReplaceAllString(strings.ToLower(a.Session.Title), "-"), "-")
+	if name == "" {
+		name = a.Session.ID
+	}
+	return filepath.Join(a.Info.Path.Root, name+format.Extension())
+}
+
+// WriteMarkdown renders the current session as Markdown, with the user and
+// assistant turns, tool calls, edit diffs and command output.
+func (a *App) WriteMarkdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", a.Session.Title)
+	for _, message := range a.exportedMessages() {
+		switch message.Role {
+		case opencode.MessageRoleUser:
+			b.WriteString("\n## User\n")
+		case opencode.MessageRoleAssistant:
+			fmt.Fprintf(&b, "\n## Assistant (%s)\n", message.Metadata.Assistant.ModelID)
+		}
+		for _, part := range message.Parts {
+			switch part := part.AsUnion().(type) {
+			case opencode.TextPart:
+				fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(part.Text))
+			case opencode.FilePart:
+				fmt.Fprintf(&b, "\n_Attachment: %s_\n", part.Filename)
+			case opencode.ToolInvocationPart:
+				b.WriteString(exportToolCall(part, message.Metadata))
+			}
+		}
+	}
+	return b.String()
+}
+
+func exportToolCall(part opencode.ToolInvocationPart, metadata opencode.MessageMetadata) string {
+	invocation := part.ToolInvocation
+	args, _ := invocation.Args.(map[string]any)
+	tool := metadata.Tool[invocation.ToolCallID]
+	str := func(m map[string]any, key string) string {
+		value, _ := m[key].(string)
+		return value
+	}
+
+	title := fmt.Sprintf("\n**Tool: %s**", invocation.ToolName)
+	for _, key := range []string{"filePath", "description", "url", "pattern", "path"} {
+		if value := str(args, key); value != "" {
+			if key == "filePath" || key == "path" {
+				value = util.Relative(value)
+			}
+			title += " `" + value + "`"
+			break
+		}
+	}
+	title += "\n"
+
+	switch invocation.ToolName {
+	case "edit":
+		if diff := str(tool.ExtraFields, "diff"); diff != "" {
+			return title + "\n```diff\n" + strings.TrimRight(diff, "\n") + "\n```\n"
+		}
+	case "bash":
+		command := str(args, "command")
+		stdout := str(tool.ExtraFields, "stdout")
+		return title + "\n```console\n$ " + command + "\n" + strings.TrimRight(stdout, "\n") + "\n```\n"
+	}
+	return title
+}
+
+// jsonExport is the layout of a JSON export.
+type jsonExport struct {
+	Info     json.RawMessage   `json:"info"`
+	Messages []json.RawMessage `json:"messages"`
+}
+
+// exportedMessages are the messages of the current session as the server
+// will see them on the next prompt: without the reverted ones, or those it
+// hasn't stored yet.
+func (a *App) exportedMessages() []opencode.Message {
+	var messages []opencode.Message
+	for _, message := range a.VisibleMessages() {
+		if !strings.HasPrefix(message.ID, "optimistic-") {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// WriteJSON returns the session info and the raw messages of the current
+// session.
+func (a *App) WriteJSON() ([]byte, error) {
+	info, err := rawJSON(a.Session.JSON.RawJSON(), a.Session)
+	if err != nil {
+		return nil, err
+	}
+	messages := a.exportedMessages()
+	export := jsonExport{Info: info, Messages: make([]json.RawMessage, 0, len(messages))}
+	for _, message := range messages {
+		data, err := rawJSON(message.JSON.RawJSON(), message)
+		if err != nil {
+			return nil, err
+		}
+		export.Messages = append(export.Messages, data)
+	}
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// rawJSON keeps the JSON of v as the server sent it, fields the SDK doesn't
+// know included.
+func rawJSON(raw string, v any) (json.RawMessage, error) {
+	if raw != "" {
+		return json.RawMessage(raw), nil
+	}
+	return json.Marshal(v)
+}
+
+// exportHTMLPage wraps the rendered transcript of a session in a self-contained
+// HTML page with the given background and text colors.
+func exportHTMLPage(title string, rendered string, background string, foreground string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { margin: 0; padding: 2em; background: %s; color: %s; }
+pre { margin: 0 auto; width: fit-content; font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 14px; line-height: 1.3; }
+</style>
+</head>
+<body>
+<pre>%s</pre>
+</body>
+</html>
+`, html.EscapeString(title), background, foreground, util.ANSIToHTML(rendered))
+}
+
+// ExportSession writes the current session to path, relative to the project
+// root unless absolute. The HTML export is made from transcript, the session
+// as rendered in the messages view.
+func (a *App) ExportSession(path string, format ExportFormat, transcript string) tea.Cmd {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(a.Info.Path.Root, path)
+	}
+
+	var data []byte
+	switch format {
+	case ExportJSON:
+		var err error
+		data, err = a.WriteJSON()
+		if err != nil {
+			slog.Error("Failed to encode messages", "error", err)
+			return toast.NewErrorToast("Failed to export session: " + err.Error())
+		}
+	case ExportHTML:
+		t := theme.CurrentTheme()
+		data = []byte(exportHTMLPage(a.Session.Title, transcript, util.CSSColor(t.Background()), util.CSSColor(t.Text())))
+	default:
+		data = []byte(a.WriteMarkdown())
+	}
+
+	return func() tea.Msg {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			slog.Error("Failed to create export directory", "error", err)
+			return toast.NewErrorToast("Failed to export session: " + err.Error())()
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			slog.Error("Failed to write export", "error", err)
+			return toast.NewErrorToast("Failed to export session: " + err.Error())()
+		}
+		return toast.NewSuccessToast("Session exported to " + util.Relative(path))()
+	}
+}
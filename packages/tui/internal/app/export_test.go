@@ -0,0 +1,74 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+// newExportApp has a session reverted to its second prompt, with a prompt
+// the server hasn't stored yet.
+func newExportApp(t *testing.T) *App {
+	t.Helper()
+	app := newTestApp(t, testserver.New(t))
+	var session opencode.Session
+	err := json.Unmarshal([]byte(`{
+		"id": "ses_1", "title": "Exported session", "version": "test",
+		"time": {"created": 1, "updated": 1},
+		"revert": {"messageID": "msg_3", "part": 0}
+	}`), &session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Session = &session
+	app.Messages = []opencode.Message{
+		testserver.UserMessage("msg_1", session.ID, "Kept prompt"),
+		testserver.AssistantMessage("msg_2", session.ID, "Kept reply", true),
+		testserver.UserMessage("msg_3", session.ID, "Reverted prompt"),
+		testserver.AssistantMessage("msg_4", session.ID, "Reverted reply", true),
+		testserver.UserMessage("optimistic-1", session.ID, "Unsent prompt"),
+	}
+	return app
+}
+
+func TestWriteJSON(t *testing.T) {
+	app := newExportApp(t)
+	data, err := app.WriteJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export struct {
+		Info     opencode.Session   `json:"info"`
+		Messages []opencode.Message `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("invalid export: %v\n%s", err, data)
+	}
+	if export.Info.Title != "Exported session" {
+		t.Errorf("title is %q, want the session title", export.Info.Title)
+	}
+	var ids []string
+	for _, message := range export.Messages {
+		ids = append(ids, message.ID)
+	}
+	if got := strings.Join(ids, ","); got != "msg_1,msg_2" {
+		t.Errorf("exported messages %s, want msg_1,msg_2", got)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	markdown := newExportApp(t).WriteMarkdown()
+	for _, want := range []string{"# Exported session", "Kept prompt", "Kept reply"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, markdown)
+		}
+	}
+	for _, unwanted := range []string{"Reverted prompt", "Reverted reply", "Unsent prompt"} {
+		if strings.Contains(markdown, unwanted) {
+			t.Errorf("markdown has %q:\n%s", unwanted, markdown)
+		}
+	}
+}
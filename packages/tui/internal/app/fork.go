@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/components/toast"
+)
+
+// SessionFork is the session and message a session was forked from.
+type SessionFork struct {
+	SessionID string `json:"sessionID"`
+	MessageID string `json:"messageID"`
+}
+
+// ForkedFrom returns where session was forked from, if it is a fork.
+func ForkedFrom(session opencode.Session) (SessionFork, bool) {
+	field, ok := session.JSON.ExtraFields["forkedFrom"]
+	if !ok || field.IsNull() {
+		return SessionFork{}, false
+	}
+	var fork SessionFork
+	if err := json.Unmarshal([]byte(field.Raw()), &fork); err != nil || fork.SessionID == "" {
+		return SessionFork{}, false
+	}
+	return fork, true
+}
+
+// ForkSession creates a session holding the messages of the current one up
+// to and including the given one, and opens it.
+func (a *App) ForkSession(ctx context.Context, messageID string) tea.Cmd {
+	sessionID := a.Session.ID
+	return func() tea.Msg {
+		var session opencode.Session
+		params := map[string]any{"messageID": messageID}
+		err := a.Client.Post(ctx, "session/"+sessionID+"/fork", params, &session)
+		if err != nil {
+			slog.Error("Failed to fork session", "error", err)
+			return toast.NewErrorToast("Failed to fork: " + err.Error())()
+		}
+		return SessionSelectedMsg(&session)
+	}
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestForkSession(t *testing.T) {
+	server := testserver.New(t)
+	session := testserver.NewSession("ses_1", "Session")
+	server.AddSession(session)
+	server.AddMessage(testserver.UserMessage("msg_1", session.ID, "First prompt"))
+	server.AddMessage(testserver.AssistantMessage("msg_2", session.ID, "First reply", true))
+	server.AddMessage(testserver.UserMessage("msg_3", session.ID, "Second prompt"))
+	app := newTestApp(t, server)
+	app.Session = &session
+
+	msg, ok := app.ForkSession(context.Background(), "msg_2")().(SessionSelectedMsg)
+	if !ok {
+		t.Fatalf("fork did not open a session")
+	}
+	fork, ok := ForkedFrom(*msg)
+	if !ok || fork != (SessionFork{SessionID: session.ID, MessageID: "msg_2"}) {
+		t.Errorf("forked from %+v, want %s at msg_2", fork, session.ID)
+	}
+	if msg.ParentID != "" {
+		t.Errorf("fork has parent %q, want none", msg.ParentID)
+	}
+	if got := len(server.Messages(msg.ID)); got != 2 {
+		t.Errorf("fork has %d messages, want 2", got)
+	}
+}
+
+func TestSelectSession(t *testing.T) {
+	server := testserver.New(t)
+	session := testserver.NewSession("ses_1", "Session")
+	child := testserver.NewChildSession("ses_2", session.ID, "Task")
+	fork := testserver.NewForkedSession("ses_3", session.ID, "msg_1", "Fork of Session")
+	server.AddMessage(testserver.UserMessage("msg_1", child.ID, "Explore"))
+	app := newTestApp(t, server)
+
+	for _, tt := range []struct {
+		name     string
+		session  opencode.Session
+		readOnly bool
+	}{
+		{name: "top level", session: session},
+		{name: "task", session: child, readOnly: true},
+		{name: "fork", session: fork},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			switch msg := app.SelectSession(context.Background(), tt.session)().(type) {
+			case SessionSelectedMsg:
+				if tt.readOnly || msg.ID != tt.session.ID {
+					t.Errorf("opened %s, want %s read only %v", msg.ID, tt.session.ID, tt.readOnly)
+				}
+			case TaskSessionOpenedMsg:
+				if !tt.readOnly || msg.Session.ID != tt.session.ID || len(msg.Messages) != 1 {
+					t.Errorf("opened %s read only with %d messages, want %s", msg.Session.ID, len(msg.Messages), tt.session.ID)
+				}
+			default:
+				t.Fatalf("selecting returned %#v", msg)
+			}
+		})
+	}
+}
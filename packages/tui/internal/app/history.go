@@ -0,0 +1,67 @@
+package app
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// historyLimit is how many prompts are remembered per project.
+const historyLimit = 500
+
+// projectKey names the files kept for the project next to the TUI state.
+func (a *App) projectKey() string {
+	return strings.Trim(nonSlugRE.ReplaceAllString(strings.ToLower(a.Info.Path.Root), "-"), "-")
+}
+
+// historyPath is where the prompt history of the project is kept.
+func (a *App) historyPath() string {
+	return filepath.Join(filepath.Dir(a.StatePath), "history", a.projectKey()+".json")
+}
+
+func (a *App) loadHistory() {
+	data, err := os.ReadFile(a.historyPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read prompt history", "error", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &a.history); err != nil {
+		slog.Warn("Failed to decode prompt history", "error", err)
+	}
+}
+
+// History returns the prompts sent in the project, oldest first.
+func (a *App) History() []string {
+	return a.history
+}
+
+// AddToHistory remembers a sent prompt, moving it to the end if it was sent
+// before, and forgets the oldest prompts past the limit.
+func (a *App) AddToHistory(prompt string) {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return
+	}
+	a.history = slices.DeleteFunc(a.history, func(p string) bool { return p == prompt })
+	a.history = append(a.history, prompt)
+	if len(a.history) > historyLimit {
+		a.history = slices.Clone(a.history[len(a.history)-historyLimit:])
+	}
+
+	path := a.historyPath()
+	data, err := json.Marshal(a.history)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		slog.Error("Failed to save prompt history", "error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestHistory(t *testing.T) {
+	server := testserver.New(t)
+	dir := t.TempDir()
+	open := func(root string) *App {
+		t.Helper()
+		info := testserver.AppIn(dir)
+		info.Path.Root = root
+		app, err := New(context.Background(), "test", info, server.Client())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return app
+	}
+
+	app := open("/project")
+	for _, prompt := range []string{"first", "  second  ", "", "first", "third"} {
+		app.AddToHistory(prompt)
+	}
+	want := []string{"second", "first", "third"}
+	if got := app.History(); !slices.Equal(got, want) {
+		t.Errorf("history is %v, want %v", got, want)
+	}
+
+	// the history is kept per project
+	if got := open("/project").History(); !slices.Equal(got, want) {
+		t.Errorf("history after a restart is %v, want %v", got, want)
+	}
+	if got := open("/other").History(); len(got) != 0 {
+		t.Errorf("another project has the history %v", got)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	for i := range historyLimit + 10 {
+		app.AddToHistory(fmt.Sprintf("prompt %d", i))
+	}
+	history := app.History()
+	if len(history) != historyLimit || history[0] != "prompt 10" {
+		t.Errorf("history has %d prompts from %q, want %d from prompt 10", len(history), history[0], historyLimit)
+	}
+}
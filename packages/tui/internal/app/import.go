@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/components/toast"
+)
+
+var assistantHeadingRE = regexp.MustCompile(`^## Assistant \((.*)\)$`)
+
+// ImportSession reads a session exported as JSON or Markdown from path,
+// relative to the project root unless absolute, recreates it as a new session
+// and opens it.
+func (a *App) ImportSession(ctx context.Context, path string) tea.Cmd {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(a.Info.Path.Root, path)
+	}
+	return func() tea.Msg {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Error("Failed to read import", "error", err)
+			return toast.NewErrorToast("Failed to import session: " + err.Error())()
+		}
+
+		var params map[string]any
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			params, err = importJSON(filepath.Base(path), data)
+		} else {
+			params, err = a.importMarkdown(filepath.Base(path), string(data))
+		}
+		if err != nil {
+			slog.Error("Failed to parse import", "error", err)
+			return toast.NewErrorToast("Failed to import session: " + err.Error())()
+		}
+
+		var session opencode.Session
+		if err := a.Client.Post(ctx, "session/import", params, &session); err != nil {
+			slog.Error("Failed to import session", "error", err)
+			return toast.NewErrorToast("Failed to import session: " + err.Error())()
+		}
+		return SessionSelectedMsg(&session)
+	}
+}
+
+// importJSON takes the messages of a JSON export as they are, since they are
+// the messages the server sent, and the title of the exported session.
+func importJSON(name string, data []byte) (map[string]any, error) {
+	var export jsonExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("not a JSON export: %w", err)
+	}
+	if len(export.Messages) == 0 {
+		return nil, errors.New("no messages to import")
+	}
+	title := "Import of " + name
+	var info struct {
+		Title string `json:"title"`
+	}
+	if json.Unmarshal(export.Info, &info) == nil && strings.TrimSpace(info.Title) != "" {
+		title = info.Title
+	}
+	return map[string]any{
+		"title":    title,
+		"messages": export.Messages,
+	}, nil
+}
+
+// importMarkdown rebuilds the turns of a Markdown export as text messages.
+// Tool calls are kept as the text they were exported as. Anything before the
+// first turn but the title is not something an export writes, so rather than
+// dropping it the import fails.
+func (a *App) importMarkdown(name string, data string) (map[string]any, error) {
+	title := "Import of " + name
+	type turn struct {
+		role    opencode.MessageRole
+		modelID string
+		text    []string
+	}
+	var turns []*turn
+	titled := false
+	for i, line := range strings.Split(data, "\n") {
+		switch {
+		case len(turns) == 0 && !titled && strings.HasPrefix(line, "# "):
+			title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			titled = true
+		case line == "## User":
+			turns = append(turns, &turn{role: opencode.MessageRoleUser})
+		case assistantHeadingRE.MatchString(line):
+			modelID := assistantHeadingRE.FindStringSubmatch(line)[1]
+			turns = append(turns, &turn{role: opencode.MessageRoleAssistant, modelID: modelID})
+		case len(turns) > 0:
+			current := turns[len(turns)-1]
+			current.text = append(current.text, line)
+		case strings.TrimSpace(line) != "":
+			return nil, fmt.Errorf("line %d is not part of a turn, expected ## User or ## Assistant (model)", i+1)
+		}
+	}
+	if len(turns) == 0 {
+		return nil, errors.New("no messages to import")
+	}
+
+	providerID := ""
+	if a.Provider != nil {
+		providerID = a.Provider.ID
+	}
+	now := time.Now().UnixMilli()
+	messages := make([]map[string]any, 0, len(turns))
+	for i, turn := range turns {
+		metadata := map[string]any{
+			"sessionID": "",
+			"time":      map[string]any{"created": now, "completed": now},
+			"tool":      map[string]any{},
+		}
+		if turn.role == opencode.MessageRoleAssistant {
+			metadata["assistant"] = map[string]any{
+				"modelID":    turn.modelID,
+				"providerID": providerID,
+				"cost":       0,
+				"path":       map[string]any{"cwd": a.Info.Path.Cwd, "root": a.Info.Path.Root},
+				"system":     []string{},
+				"tokens": map[string]any{
+					"input": 0, "output": 0, "reasoning": 0,
+					"cache": map[string]any{"read": 0, "write": 0},
+				},
+			}
+		}
+		messages = append(messages, map[string]any{
+			// the server gives imported messages ids of its own
+			"id":       fmt.Sprintf("msg_import_%d", i),
+			"role":     turn.role,
+			"parts":    []any{map[string]any{"type": "text", "text": strings.TrimSpace(strings.Join(turn.text, "\n"))}},
+			"metadata": metadata,
+		})
+	}
+	return map[string]any{
+		"title":    title,
+		"messages": messages,
+	}, nil
+}
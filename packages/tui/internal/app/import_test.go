@@ -0,0 +1,116 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestImportJSON(t *testing.T) {
+	data, err := newExportApp(t).WriteJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		data      string
+		title     string
+		messages  int
+		wantError bool
+	}{
+		{name: "export", data: string(data), title: "Exported session", messages: 2},
+		{name: "untitled", data: `{"info": {"title": " "}, "messages": [{}]}`, title: "Import of session.json", messages: 1},
+		{name: "no info", data: `{"messages": [{}]}`, title: "Import of session.json", messages: 1},
+		{name: "no messages", data: `{"info": {"title": "Empty"}, "messages": []}`, wantError: true},
+		{name: "not JSON", data: `# Session`, wantError: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := importJSON("session.json", []byte(tt.data))
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("imported %v, want an error", params)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if params["title"] != tt.title {
+				t.Errorf("title is %q, want %q", params["title"], tt.title)
+			}
+			if messages := params["messages"].([]json.RawMessage); len(messages) != tt.messages {
+				t.Errorf("imported %d messages, want %d", len(messages), tt.messages)
+			}
+		})
+	}
+}
+
+func TestImportMarkdown(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+
+	for _, tt := range []struct {
+		name     string
+		data     string
+		title    string
+		roles    []string
+		wantText string
+		wantErr  bool
+	}{
+		{
+			name:     "export",
+			data:     newExportApp(t).WriteMarkdown(),
+			title:    "Exported session",
+			roles:    []string{"user", "assistant"},
+			wantText: "Kept reply",
+		},
+		{
+			name:     "untitled",
+			data:     "## User\n\nHello\n",
+			title:    "Import of session.md",
+			roles:    []string{"user"},
+			wantText: "Hello",
+		},
+		{
+			name:     "headings in a reply",
+			data:     "# Notes\n\n## User\n\nHi\n\n## Assistant (test-model)\n\n# Plan\n\n## Steps\n",
+			title:    "Notes",
+			roles:    []string{"user", "assistant"},
+			wantText: "# Plan\n\n## Steps",
+		},
+		{name: "no turns", data: "# Notes\n\n", wantErr: true},
+		{name: "text before the first turn", data: "# Notes\n\nSome context\n\n## User\n\nHi\n", wantErr: true},
+		{name: "unknown section", data: "# Notes\n\n## Summary\n\n## User\n\nHi\n", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := app.importMarkdown("session.md", tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("imported %v, want an error", params)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if params["title"] != tt.title {
+				t.Errorf("title is %q, want %q", params["title"], tt.title)
+			}
+			messages := params["messages"].([]map[string]any)
+			if len(messages) != len(tt.roles) {
+				t.Fatalf("imported %d messages, want %d", len(messages), len(tt.roles))
+			}
+			for i, message := range messages {
+				if role := string(message["role"].(opencode.MessageRole)); role != tt.roles[i] {
+					t.Errorf("message %d is a %s message, want %s", i, role, tt.roles[i])
+				}
+			}
+			last := messages[len(messages)-1]["parts"].([]any)[0].(map[string]any)
+			if last["text"] != tt.wantText {
+				t.Errorf("last message is %q, want %q", last["text"], tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Ways of notifying, set as notifications.method in the opencode config.
+const (
+	NotifyBell   = "bell"
+	NotifyOSC9   = "osc9"
+	NotifyOSC777 = "osc777"
+	NotifyNone   = "none"
+)
+
+// notifyCommandTimeout bounds how long the notify command may take.
+const notifyCommandTimeout = 10 * time.Second
+
+// Notification is something worth telling the user about while they are in
+// another window.
+type Notification struct {
+	Title string
+	Body  string
+}
+
+// MessageNotification returns the notification for a message in an open
+// session that has just completed or had a tool fail. Each is only reported
+// once, however many times the message is updated.
+func (a *App) MessageNotification(message opencode.Message) (Notification, bool) {
+	if message.Role != opencode.MessageRoleAssistant {
+		return Notification{}, false
+	}
+	a.SyncTab()
+	tabs := a.tabs
+	i := slices.IndexFunc(tabs, func(tab Tab) bool {
+		return tab.Session.ID == message.Metadata.SessionID
+	})
+	if i < 0 {
+		return Notification{}, false
+	}
+	title := tabs[i].Title()
+	if a.notified == nil {
+		a.notified = make(map[string]bool)
+	}
+
+	for id, tool := range message.Metadata.Tool {
+		if failed, ok := tool.ExtraFields["error"].(bool); !ok || !failed || a.notified[id] {
+			continue
+		}
+		a.notified[id] = true
+		body := "A tool failed"
+		if text, ok := tool.ExtraFields["message"].(string); ok && text != "" {
+			body += ": " + text
+		}
+		return Notification{Title: title, Body: body}, true
+	}
+
+	if message.Metadata.Time.Completed > 0 && !a.notified[message.ID] {
+		a.notified[message.ID] = true
+		if message.Metadata.Error.Name != "" {
+			return Notification{}, false // reported by the session error
+		}
+		return Notification{Title: title, Body: "Response finished"}, true
+	}
+	return Notification{}, false
+}
+
+// Notify tells the user about n the ways the config sets: the terminal
+// bell, a desktop notification through OSC 9 or OSC 777, and the notify
+// command, which gets the title and body in OPENCODE_NOTIFY_TITLE and
+// OPENCODE_NOTIFY_BODY.
+func (a *App) Notify(n Notification) tea.Cmd {
+	title := "opencode"
+	if n.Title != "" {
+		title += ": " + n.Title
+	}
+	var cmds []tea.Cmd
+	switch a.Notifications.Method {
+	case NotifyBell:
+		cmds = append(cmds, tea.Raw("\a"))
+	case NotifyOSC9:
+		cmds = append(cmds, tea.Raw(ansi.Notify(oscText(title+": "+n.Body))))
+	case NotifyOSC777:
+		cmds = append(cmds, tea.Raw("\x1b]777;notify;"+oscText(title)+";"+oscText(n.Body)+"\x07"))
+	}
+	if command := a.Notifications.Command; command != "" {
+		cwd := a.Info.Path.Cwd
+		cmds = append(cmds, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyCommandTimeout)
+			defer cancel()
+			cmd := exec.CommandContext(ctx, userShell(), "-c", command)
+			cmd.Dir = cwd
+			cmd.Env = append(os.Environ(),
+				"OPENCODE_NOTIFY_TITLE="+title,
+				"OPENCODE_NOTIFY_BODY="+n.Body,
+			)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				slog.Error("Failed to run notify command", "error", err, "output", string(output))
+			}
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// oscText makes text safe to put in an OSC sequence, where control
+// characters would end it early and semicolons separate fields.
+func oscText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		if r == ';' {
+			return ','
+		}
+		return r
+	}, text)
+}
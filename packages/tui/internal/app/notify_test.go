@@ -0,0 +1,62 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestNotificationsConfig(t *testing.T) {
+	server := testserver.New(t)
+	if got := newTestApp(t, server).Notifications.Method; got != NotifyBell {
+		t.Errorf("method defaults to %q, want %q", got, NotifyBell)
+	}
+	server.Configure(`{"notifications": {"method": "osc9", "command": "true"}}`)
+	if got := newTestApp(t, server).Notifications; got.Method != NotifyOSC9 || got.Command != "true" {
+		t.Errorf("notifications = %+v, want them from the config", got)
+	}
+}
+
+func TestMessageNotification(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	session := testserver.NewSession("ses_1", "Fix the parser")
+	app.Session = &session
+
+	var failed opencode.Message
+	if err := json.Unmarshal([]byte(`{
+		"id": "msg_1",
+		"role": "assistant",
+		"parts": [],
+		"metadata": {
+			"sessionID": "ses_1",
+			"time": {"created": 1, "completed": 2},
+			"tool": {"call_1": {"title": "bash", "time": {"start": 1, "end": 2}, "error": true, "message": "exit status 1"}}
+		}
+	}`), &failed); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		message opencode.Message
+		want    string
+	}{
+		{name: "user message", message: testserver.UserMessage("msg_0", session.ID, "Hi")},
+		{name: "other session", message: testserver.AssistantMessage("msg_9", "ses_2", "Done.", true)},
+		{name: "in progress", message: testserver.AssistantMessage("msg_2", session.ID, "Working", false)},
+		{name: "finished", message: testserver.AssistantMessage("msg_2", session.ID, "Done.", true), want: "Response finished"},
+		{name: "finished again", message: testserver.AssistantMessage("msg_2", session.ID, "Done.", true)},
+		{name: "tool failed", message: failed, want: "A tool failed: exit status 1"},
+		{name: "finished after the failure", message: failed, want: "Response finished"},
+	} {
+		notification, ok := app.MessageNotification(tt.message)
+		if ok != (tt.want != "") || notification.Body != tt.want {
+			t.Errorf("%s: notified %q (%v), want %q", tt.name, notification.Body, ok, tt.want)
+		}
+		if ok && notification.Title != session.Title {
+			t.Errorf("%s: notification is titled %q, want the session title", tt.name, notification.Title)
+		}
+	}
+}
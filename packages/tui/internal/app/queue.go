@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// QueuedPrompt is a prompt submitted while the agent was busy, waiting to be
+// sent once the running turn completes.
+type QueuedPrompt struct {
+	Text        string
+	Attachments []Attachment
+}
+
+// QueuedPrompts returns the prompts waiting in the current session.
+func (a *App) QueuedPrompts() []QueuedPrompt {
+	return a.queues[a.Session.ID]
+}
+
+// QueuePrompt adds a prompt to the end of the current session's queue.
+func (a *App) QueuePrompt(text string, attachments []Attachment) {
+	if a.queues == nil {
+		a.queues = make(map[string][]QueuedPrompt)
+	}
+	a.queues[a.Session.ID] = append(a.queues[a.Session.ID], QueuedPrompt{
+		Text:        text,
+		Attachments: attachments,
+	})
+}
+
+// RemoveQueuedPrompt drops the prompt at index from the current session's
+// queue and returns it.
+func (a *App) RemoveQueuedPrompt(index int) (QueuedPrompt, bool) {
+	queue := a.queues[a.Session.ID]
+	if index < 0 || index >= len(queue) {
+		return QueuedPrompt{}, false
+	}
+	prompt := queue[index]
+	a.queues[a.Session.ID] = slices.Delete(queue, index, index+1)
+	return prompt, true
+}
+
+// MoveQueuedPrompt swaps the prompt at index with its neighbour in the given
+// direction, returning its new index.
+func (a *App) MoveQueuedPrompt(index int, delta int) int {
+	queue := a.queues[a.Session.ID]
+	target := index + delta
+	if index < 0 || index >= len(queue) || target < 0 || target >= len(queue) {
+		return index
+	}
+	queue[index], queue[target] = queue[target], queue[index]
+	return target
+}
+
+// SendQueuedPrompt sends the next queued prompt of the current session once
+// the agent is idle.
+func (a *App) SendQueuedPrompt(ctx context.Context) tea.Cmd {
+	if a.IsBusy() || a.IsCompacting() {
+		return nil
+	}
+	prompt, ok := a.RemoveQueuedPrompt(0)
+	if !ok {
+		return nil
+	}
+	return a.SendChatMessage(ctx, prompt.Text, prompt.Attachments)
+}
+
+// SendQueuedPromptIn sends the next queued prompt of a session open in any
+// tab, so that queues keep flowing in the background.
+func (a *App) SendQueuedPromptIn(ctx context.Context, sessionID string) tea.Cmd {
+	if sessionID == a.Session.ID {
+		return a.SendQueuedPrompt(ctx)
+	}
+	if len(a.queues[sessionID]) == 0 {
+		return nil
+	}
+	a.SyncTab()
+	index := slices.IndexFunc(a.tabs, func(t Tab) bool { return t.Session.ID == sessionID })
+	if index < 0 {
+		return nil
+	}
+	active := a.activeTab
+	a.activateTab(index)
+	cmd := a.SendQueuedPrompt(ctx)
+	a.SyncTab()
+	a.activateTab(active)
+	return cmd
+}
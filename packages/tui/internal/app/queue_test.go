@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func queuedTexts(app *App) []string {
+	var texts []string
+	for _, prompt := range app.QueuedPrompts() {
+		texts = append(texts, prompt.Text)
+	}
+	return texts
+}
+
+func TestQueue(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	app.Session = &opencode.Session{ID: "ses_1"}
+	for _, text := range []string{"first", "second", "third"} {
+		app.QueuePrompt(text, nil)
+	}
+
+	if got := app.MoveQueuedPrompt(2, -1); got != 1 {
+		t.Errorf("moved to %d, want 1", got)
+	}
+	if got := app.MoveQueuedPrompt(0, -1); got != 0 {
+		t.Errorf("moved past the start to %d", got)
+	}
+	if got := app.MoveQueuedPrompt(5, 1); got != 5 {
+		t.Errorf("moved a missing prompt to %d", got)
+	}
+	if want := []string{"first", "third", "second"}; !slices.Equal(queuedTexts(app), want) {
+		t.Errorf("queue is %v, want %v", queuedTexts(app), want)
+	}
+
+	if prompt, ok := app.RemoveQueuedPrompt(1); !ok || prompt.Text != "third" {
+		t.Errorf("removed %+v, want the third prompt", prompt)
+	}
+	if _, ok := app.RemoveQueuedPrompt(2); ok {
+		t.Error("removed a missing prompt")
+	}
+
+	// queues belong to their session
+	app.Session = &opencode.Session{ID: "ses_2"}
+	if got := app.QueuedPrompts(); len(got) != 0 {
+		t.Errorf("another session has %d queued prompts", len(got))
+	}
+	app.Session = &opencode.Session{ID: "ses_1"}
+	if want := []string{"first", "second"}; !slices.Equal(queuedTexts(app), want) {
+		t.Errorf("queue is %v, want %v", queuedTexts(app), want)
+	}
+}
+
+func TestSendQueuedPrompt(t *testing.T) {
+	server := testserver.New(t)
+	session := testserver.NewSession("ses_1", "Session")
+	server.AddSession(session)
+	app := newTestApp(t, server)
+	app.OpenTab(&session, []opencode.Message{
+		testserver.UserMessage("msg_1", session.ID, "First prompt"),
+		testserver.AssistantMessage("msg_2", session.ID, "Working on it", false),
+	})
+	app.QueuePrompt("Second prompt", nil)
+
+	if cmd := app.SendQueuedPrompt(context.Background()); cmd != nil {
+		t.Fatal("sent a queued prompt while the agent is busy")
+	}
+	app.Messages[1] = testserver.AssistantMessage("msg_2", session.ID, "Done", true)
+	flatten(app.SendQueuedPrompt(context.Background()))
+	if messages := server.Messages(session.ID); len(messages) != 1 || messages[0].Parts[0].Text != "Second prompt" {
+		t.Fatalf("server has %+v, want the queued prompt", messages)
+	}
+	if got := len(app.QueuedPrompts()); got != 0 {
+		t.Errorf("%d prompts still queued after sending", got)
+	}
+}
+
+func TestSendQueuedPromptInBackground(t *testing.T) {
+	server := testserver.New(t)
+	background := testserver.NewSession("ses_1", "Background")
+	active := testserver.NewSession("ses_2", "Active")
+	server.AddSession(background)
+	server.AddSession(active)
+	app := newTestApp(t, server)
+	app.OpenTab(&background, nil)
+	app.QueuePrompt("Queued prompt", nil)
+	app.OpenTab(&active, nil)
+
+	flatten(app.SendQueuedPromptIn(context.Background(), background.ID))
+	if messages := server.Messages(background.ID); len(messages) != 1 || messages[0].Parts[0].Text != "Queued prompt" {
+		t.Fatalf("background session has %+v, want the queued prompt", messages)
+	}
+	if len(server.Messages(active.ID)) != 0 || app.Session.ID != active.ID {
+		t.Errorf("active session is %s with %d messages, want %s untouched",
+			app.Session.ID, len(server.Messages(active.ID)), active.ID)
+	}
+}
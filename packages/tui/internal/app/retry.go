@@ -0,0 +1,85 @@
+package app
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// continuePrompt asks the model to pick up a reply cut off by the output
+// length limit.
+const continuePrompt = "Your last reply was cut off because it reached the output length limit. Continue exactly where it stopped, without repeating anything."
+
+// RetryMsg asks for the last prompt of the current session to be sent again,
+// or, with Continue, for the model to resume a reply that was cut off.
+type RetryMsg struct {
+	Continue bool
+}
+
+// FailedTurn returns the error the last reply of the current session ended
+// with, if any.
+func (a *App) FailedTurn() (opencode.MessageMetadataError, bool) {
+	messages := a.VisibleMessages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == opencode.MessageRoleAssistant {
+			err := messages[i].Metadata.Error
+			return err, err.Name != ""
+		}
+	}
+	return opencode.MessageMetadataError{}, false
+}
+
+// RetryPrompt returns the prompt that retries the last turn of the current
+// session: the last prompt with its attachments, or a request to continue.
+func (a *App) RetryPrompt(msg RetryMsg) (SendMsg, bool) {
+	if msg.Continue {
+		return SendMsg{Text: continuePrompt}, true
+	}
+	messages := a.VisibleMessages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != opencode.MessageRoleUser {
+			continue
+		}
+		var prompt SendMsg
+		var texts []string
+		for _, part := range messages[i].Parts {
+			switch part := part.AsUnion().(type) {
+			case opencode.TextPart:
+				texts = append(texts, part.Text)
+			case opencode.FilePart:
+				if attachment, ok := partAttachment(part); ok {
+					prompt.Attachments = append(prompt.Attachments, attachment)
+				}
+			}
+		}
+		prompt.Text = strings.Join(texts, "\n\n")
+		return prompt, prompt.Text != ""
+	}
+	return SendMsg{}, false
+}
+
+// partAttachment turns a file part of a sent message back into the
+// attachment it was made from.
+func partAttachment(part opencode.FilePart) (Attachment, bool) {
+	attachment := Attachment{FileName: part.Filename, MimeType: part.MediaType}
+	if data, ok := strings.CutPrefix(part.URL, "data:"); ok {
+		_, encoded, ok := strings.Cut(data, ";base64,")
+		if !ok {
+			return Attachment{}, false
+		}
+		content, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return Attachment{}, false
+		}
+		attachment.Content = content
+		return attachment, true
+	}
+	u, err := url.Parse(part.URL)
+	if err != nil || u.Scheme != "file" {
+		return Attachment{}, false
+	}
+	attachment.FilePath = u.Path
+	return attachment, true
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/util"
+)
+
+// SessionRevert is the point a session has been reverted to. Messages from
+// there on stay stored until the next prompt drops them, or the revert is
+// undone.
+type SessionRevert struct {
+	MessageID string `json:"messageID"`
+	Part      int    `json:"part"`
+	Snapshot  string `json:"snapshot"`
+}
+
+// SessionRevertedMsg carries the session after a revert or its undo.
+type SessionRevertedMsg struct {
+	Session opencode.Session
+}
+
+// RevertPoint returns where the current session has been reverted to, if
+// anywhere.
+func (a *App) RevertPoint() (SessionRevert, bool) {
+	field, ok := a.Session.JSON.ExtraFields["revert"]
+	if !ok || field.IsNull() {
+		return SessionRevert{}, false
+	}
+	var revert SessionRevert
+	if err := json.Unmarshal([]byte(field.Raw()), &revert); err != nil || revert.MessageID == "" {
+		return SessionRevert{}, false
+	}
+	return revert, true
+}
+
+// VisibleMessages returns the messages of the current session that survive
+// its revert point, trimmed the same way the server does on the next prompt.
+func (a *App) VisibleMessages() []opencode.Message {
+	revert, ok := a.RevertPoint()
+	if !ok {
+		return a.Messages
+	}
+	visible := make([]opencode.Message, 0, len(a.Messages))
+	for _, message := range a.Messages {
+		switch {
+		case strings.HasPrefix(message.ID, "optimistic-"):
+		case message.ID > revert.MessageID:
+			continue
+		case message.ID == revert.MessageID:
+			if revert.Part == 0 {
+				continue
+			}
+			message.Parts = message.Parts[:min(revert.Part, len(message.Parts))]
+		}
+		visible = append(visible, message)
+	}
+	return visible
+}
+
+// ChangedFiles lists the files edited or written by the agent from the given
+// message on, in the order they were first touched.
+func (a *App) ChangedFiles(messageID string) []string {
+	var files []string
+	for _, message := range a.VisibleMessages() {
+		if message.ID < messageID || message.Role != opencode.MessageRoleAssistant {
+			continue
+		}
+		for _, part := range message.Parts {
+			invocation, ok := part.AsUnion().(opencode.ToolInvocationPart)
+			if !ok {
+				continue
+			}
+			if invocation.ToolInvocation.ToolName != "edit" && invocation.ToolInvocation.ToolName != "write" {
+				continue
+			}
+			args, _ := invocation.ToolInvocation.Args.(map[string]any)
+			path, _ := args["filePath"].(string)
+			if path == "" {
+				continue
+			}
+			path = util.Relative(path)
+			if !slices.Contains(files, path) {
+				files = append(files, path)
+			}
+		}
+	}
+	return files
+}
+
+// RevertMessage reverts the current session to before the given message,
+// restoring the files the agent changed since.
+func (a *App) RevertMessage(ctx context.Context, messageID string) tea.Cmd {
+	sessionID := a.Session.ID
+	return func() tea.Msg {
+		var session opencode.Session
+		params := map[string]any{"messageID": messageID, "part": 0}
+		err := a.Client.Post(ctx, "session/"+sessionID+"/revert", params, &session)
+		if err != nil {
+			slog.Error("Failed to revert session", "error", err)
+			return toast.NewErrorToast("Failed to revert: " + err.Error())()
+		}
+		return SessionRevertedMsg{Session: session}
+	}
+}
+
+// UndoRevert brings back the messages and files of the last revert.
+func (a *App) UndoRevert(ctx context.Context) tea.Cmd {
+	sessionID := a.Session.ID
+	return func() tea.Msg {
+		var session opencode.Session
+		err := a.Client.Post(ctx, "session/"+sessionID+"/unrevert", nil, &session)
+		if err != nil {
+			slog.Error("Failed to undo revert", "error", err)
+			return toast.NewErrorToast("Failed to redo: " + err.Error())()
+		}
+		return SessionRevertedMsg{Session: session}
+	}
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+// editMessage is a completed reply that edited the given files.
+func editMessage(t *testing.T, id, sessionID string, paths ...string) opencode.Message {
+	t.Helper()
+	parts := []map[string]any{}
+	for i, path := range paths {
+		parts = append(parts, map[string]any{"type": "tool-invocation", "toolInvocation": map[string]any{
+			"state":      "result",
+			"toolCallId": fmt.Sprintf("call_%d", i),
+			"toolName":   "edit",
+			"args":       map[string]any{"filePath": path},
+			"result":     "",
+		}})
+	}
+	message := testserver.AssistantMessage(id, sessionID, "", true)
+	var fields map[string]any
+	json.Unmarshal([]byte(message.JSON.RawJSON()), &fields)
+	fields["parts"] = parts
+	data, _ := json.Marshal(fields)
+	if err := json.Unmarshal(data, &message); err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+// revertedTo returns the session ses_1 reverted to the given point.
+func revertedTo(t *testing.T, messageID string, part int) *opencode.Session {
+	t.Helper()
+	var session opencode.Session
+	data := fmt.Sprintf(`{"id": "ses_1", "title": "Session", "version": "test", "time": {"created": 1, "updated": 1},
+		"revert": {"messageID": %q, "part": %d}}`, messageID, part)
+	if messageID == "" {
+		data = `{"id": "ses_1", "title": "Session", "version": "test", "time": {"created": 1, "updated": 1}}`
+	}
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		t.Fatal(err)
+	}
+	return &session
+}
+
+func TestVisibleMessages(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	app.Messages = []opencode.Message{
+		testserver.UserMessage("msg_1", "ses_1", "Delegate"),
+		testserver.TaskMessage("msg_2", "ses_1", "ses_2", "Explore"),
+		testserver.UserMessage("msg_3", "ses_1", "Next"),
+		testserver.AssistantMessage("msg_4", "ses_1", "Reply", true),
+		testserver.UserMessage("optimistic-1", "ses_1", "Unsent"),
+	}
+
+	// prompts not stored yet are shown whatever the revert point
+	for _, tt := range []struct {
+		name      string
+		messageID string
+		part      int
+		want      []string
+		taskParts int
+	}{
+		{name: "not reverted", want: []string{"msg_1", "msg_2", "msg_3", "msg_4", "optimistic-1"}, taskParts: 2},
+		{name: "whole message", messageID: "msg_3", want: []string{"msg_1", "msg_2", "optimistic-1"}, taskParts: 2},
+		{name: "part of a message", messageID: "msg_2", part: 1, want: []string{"msg_1", "msg_2", "optimistic-1"}, taskParts: 1},
+		{name: "first message", messageID: "msg_1", want: []string{"optimistic-1"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app.Session = revertedTo(t, tt.messageID, tt.part)
+			visible := app.VisibleMessages()
+			var ids []string
+			for _, message := range visible {
+				ids = append(ids, message.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Fatalf("visible messages are %v, want %v", ids, tt.want)
+			}
+			if tt.taskParts > 0 && len(visible[1].Parts) != tt.taskParts {
+				t.Errorf("task message has %d parts, want %d", len(visible[1].Parts), tt.taskParts)
+			}
+		})
+	}
+	if len(app.Messages[1].Parts) != 2 {
+		t.Error("trimming a reverted message changed the stored one")
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	app.Session = revertedTo(t, "", 0)
+	app.Messages = []opencode.Message{
+		testserver.UserMessage("msg_1", "ses_1", "Fix it"),
+		editMessage(t, "msg_2", "ses_1", testserver.Root+"/main.go"),
+		testserver.UserMessage("msg_3", "ses_1", "And the tests"),
+		editMessage(t, "msg_4", "ses_1", testserver.Root+"/main_test.go", testserver.Root+"/main.go"),
+	}
+
+	if got, want := app.ChangedFiles("msg_1"), []string{"main.go", "main_test.go"}; !slices.Equal(got, want) {
+		t.Errorf("changed from msg_1: %v, want %v", got, want)
+	}
+	if got, want := app.ChangedFiles("msg_3"), []string{"main_test.go", "main.go"}; !slices.Equal(got, want) {
+		t.Errorf("changed from msg_3: %v, want %v", got, want)
+	}
+	app.Session = revertedTo(t, "msg_3", 0)
+	if got := app.ChangedFiles("msg_3"); len(got) != 0 {
+		t.Errorf("reverted messages changed %v", got)
+	}
+}
+
+func TestRevertMessage(t *testing.T) {
+	server := testserver.New(t)
+	session := testserver.NewSession("ses_1", "Session")
+	server.AddSession(session)
+	app := newTestApp(t, server)
+	app.Session = &session
+
+	msg, ok := app.RevertMessage(context.Background(), "msg_3")().(SessionRevertedMsg)
+	if !ok {
+		t.Fatal("revert failed")
+	}
+	app.Session = &msg.Session
+	if revert, ok := app.RevertPoint(); !ok || revert.MessageID != "msg_3" {
+		t.Fatalf("session is reverted to %+v, want msg_3", revert)
+	}
+
+	msg, ok = app.UndoRevert(context.Background())().(SessionRevertedMsg)
+	if !ok {
+		t.Fatal("undoing the revert failed")
+	}
+	app.Session = &msg.Session
+	if revert, ok := app.RevertPoint(); ok {
+		t.Errorf("session is still reverted to %+v", revert)
+	}
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// SessionStats summarizes the messages of a session.
+type SessionStats struct {
+	Messages int
+	Cost     float64
+	// Models are the models that replied, in the order they were first used.
+	Models []string
+}
+
+// SessionStats loads the messages of a session and summarizes them.
+func (a *App) SessionStats(ctx context.Context, sessionID string) (SessionStats, error) {
+	messages, err := a.ListMessages(ctx, sessionID)
+	if err != nil {
+		return SessionStats{}, err
+	}
+	stats := SessionStats{Messages: len(messages)}
+	for _, message := range messages {
+		if message.Role != opencode.MessageRoleAssistant {
+			continue
+		}
+		stats.Cost += message.Metadata.Assistant.Cost
+		model := message.Metadata.Assistant.ModelID
+		if model != "" && !slices.Contains(stats.Models, model) {
+			stats.Models = append(stats.Models, model)
+		}
+	}
+	return stats, nil
+}
+
+// RenameSession changes the title of a session.
+func (a *App) RenameSession(ctx context.Context, sessionID string, title string) (*opencode.Session, error) {
+	var session opencode.Session
+	params := map[string]any{"title": title}
+	err := a.Client.Patch(ctx, "session/"+sessionID, params, &session)
+	if err != nil {
+		slog.Error("Failed to rename session", "error", err)
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+)
+
+// ShareChangedMsg reports a session after it was shared or unshared.
+type ShareChangedMsg struct {
+	Session opencode.Session
+	Shared  bool
+	Err     error
+}
+
+// ShareSession creates a public link to the current session.
+func (a *App) ShareSession(ctx context.Context) tea.Cmd {
+	sessionID := a.Session.ID
+	return func() tea.Msg {
+		session, err := a.Client.Session.Share(ctx, sessionID)
+		if err != nil {
+			slog.Error("Failed to share session", "error", err)
+			return ShareChangedMsg{Shared: true, Err: err}
+		}
+		return ShareChangedMsg{Session: *session, Shared: true}
+	}
+}
+
+// UnshareSession removes the public link to the current session.
+func (a *App) UnshareSession(ctx context.Context) tea.Cmd {
+	sessionID := a.Session.ID
+	return func() tea.Msg {
+		session, err := a.Client.Session.Unshare(ctx, sessionID)
+		if err != nil {
+			slog.Error("Failed to unshare session", "error", err)
+			return ShareChangedMsg{Err: err}
+		}
+		return ShareChangedMsg{Session: *session}
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// shellTimeout bounds how long a command run with !command may take.
+const shellTimeout = 2 * time.Minute
+
+// shellOutputLimit is how much of a command's output is kept, from its end.
+const shellOutputLimit = 32 * 1024
+
+// ShellResult is the outcome of a command run with !command, attached to the
+// next prompt of the session it was run in.
+type ShellResult struct {
+	Command   string
+	Output    string
+	ExitCode  int
+	Truncated bool
+	TimedOut  bool
+}
+
+// Status summarises how the command ended.
+func (r ShellResult) Status() string {
+	status := fmt.Sprintf("exit %d", r.ExitCode)
+	if r.TimedOut {
+		status = "timed out after " + shellTimeout.String()
+	}
+	if r.Truncated {
+		status += ", output truncated"
+	}
+	return status
+}
+
+// ShellRequestedMsg asks for a command typed as !command to be run.
+type ShellRequestedMsg struct {
+	Command string
+}
+
+// ShellFinishedMsg carries the result of a command run in a session.
+type ShellFinishedMsg struct {
+	SessionID string
+	Result    ShellResult
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	data      []byte
+	limit     int
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = b.data[len(b.data)-b.limit:]
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// userShell is the shell commands are run with.
+func userShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "sh"
+}
+
+// RunShell runs a command in the working directory with the user's shell,
+// capturing its output.
+func (a *App) RunShell(ctx context.Context, command string) tea.Cmd {
+	sessionID := a.Session.ID
+	cwd := a.Info.Path.Cwd
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, shellTimeout)
+		defer cancel()
+
+		output := &tailBuffer{limit: shellOutputLimit}
+		cmd := exec.CommandContext(ctx, userShell(), "-c", command)
+		cmd.Dir = cwd
+		cmd.Stdout = output
+		cmd.Stderr = output
+		// don't wait on background processes holding the output open
+		cmd.WaitDelay = time.Second
+		err := cmd.Run()
+
+		result := ShellResult{
+			Command:   command,
+			Output:    string(output.data),
+			ExitCode:  cmd.ProcessState.ExitCode(),
+			Truncated: output.truncated,
+			TimedOut:  errors.Is(ctx.Err(), context.DeadlineExceeded),
+		}
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) && !result.TimedOut {
+			result.Output += err.Error()
+		}
+		return ShellFinishedMsg{SessionID: sessionID, Result: result}
+	}
+}
+
+// ShellResults returns the command output waiting to be attached to the next
+// prompt of the current session.
+func (a *App) ShellResults() []ShellResult {
+	return a.shells[a.Session.ID]
+}
+
+// AddShellResult keeps the output of a command for the next prompt of the
+// session it was run in.
+func (a *App) AddShellResult(sessionID string, result ShellResult) {
+	if a.shells == nil {
+		a.shells = make(map[string][]ShellResult)
+	}
+	a.shells[sessionID] = append(a.shells[sessionID], result)
+}
+
+// ClearShellResults discards the command output waiting in the current
+// session.
+func (a *App) ClearShellResults() {
+	delete(a.shells, a.Session.ID)
+}
+
+// AttachShellOutput appends the command output waiting in the current
+// session to a prompt, as context for the agent.
+func (a *App) AttachShellOutput(text string) string {
+	results := a.ShellResults()
+	if len(results) == 0 {
+		return text
+	}
+	a.ClearShellResults()
+	var b strings.Builder
+	b.WriteString(text)
+	for _, result := range results {
+		fmt.Fprintf(&b, "\n\nOutput of `%s` (%s):\n```console\n$ %s\n%s\n```",
+			result.Command, result.Status(), result.Command, strings.TrimRight(result.Output, "\n"))
+	}
+	return b.String()
+}
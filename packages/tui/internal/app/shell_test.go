@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestRunShell(t *testing.T) {
+	t.Setenv("SHELL", "sh")
+	app := newTestApp(t, testserver.New(t))
+	app.Info.Path.Cwd = t.TempDir()
+	app.Session = &opencode.Session{ID: "ses_1"}
+
+	for _, tt := range []struct {
+		name      string
+		command   string
+		output    string
+		exitCode  int
+		truncated bool
+	}{
+		{name: "output", command: "echo hi", output: "hi\n"},
+		{name: "stderr", command: "echo oops >&2; exit 3", output: "oops\n", exitCode: 3},
+		{name: "working directory", command: "pwd", output: app.Info.Path.Cwd + "\n"},
+		{name: "long output", command: "yes | head -c 40000", truncated: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, ok := app.RunShell(context.Background(), tt.command)().(ShellFinishedMsg)
+			if !ok || msg.SessionID != "ses_1" {
+				t.Fatalf("got %+v, want the result for ses_1", msg)
+			}
+			result := msg.Result
+			if tt.output != "" && result.Output != tt.output {
+				t.Errorf("output is %q, want %q", result.Output, tt.output)
+			}
+			if result.ExitCode != tt.exitCode || result.Truncated != tt.truncated {
+				t.Errorf("got exit %d truncated %v, want exit %d truncated %v",
+					result.ExitCode, result.Truncated, tt.exitCode, tt.truncated)
+			}
+			if tt.truncated && len(result.Output) != shellOutputLimit {
+				t.Errorf("kept %d bytes of output, want %d", len(result.Output), shellOutputLimit)
+			}
+		})
+	}
+}
+
+func TestAttachShellOutput(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	app.Session = &opencode.Session{ID: "ses_1"}
+	app.AddShellResult("ses_1", ShellResult{Command: "go test", Output: "ok\n"})
+	app.AddShellResult("ses_1", ShellResult{Command: "false", ExitCode: 1})
+	app.AddShellResult("ses_2", ShellResult{Command: "ls", Output: "main.go\n"})
+
+	got := app.AttachShellOutput("why?")
+	want := "why?\n\nOutput of `go test` (exit 0):\n```console\n$ go test\nok\n```" +
+		"\n\nOutput of `false` (exit 1):\n```console\n$ false\n\n```"
+	if got != want {
+		t.Errorf("prompt is %q, want %q", got, want)
+	}
+	if got := app.AttachShellOutput("again"); got != "again" {
+		t.Errorf("output was attached twice: %q", got)
+	}
+	if strings.Contains(got, "main.go") || len(app.shells["ses_2"]) != 1 {
+		t.Error("output of another session was attached")
+	}
+}
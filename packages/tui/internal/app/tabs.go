@@ -0,0 +1,219 @@
+package app
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Tab is a session open next to others. The active tab's state lives in
+// App.Session and App.Messages; the others keep theirs here and are updated
+// from events in the background.
+type Tab struct {
+	Session  *opencode.Session
+	Messages []opencode.Message
+	// ReadOnly is set for subagent sessions opened from a task, which are
+	// only there to be looked at.
+	ReadOnly bool
+}
+
+func emptyTab() Tab {
+	return Tab{Session: &opencode.Session{}, Messages: []opencode.Message{}}
+}
+
+// Title is the session title, or a placeholder before the first prompt.
+func (t Tab) Title() string {
+	if t.Session.ID == "" {
+		return "New session"
+	}
+	return t.Session.Title
+}
+
+// IsBusy reports whether the agent is working in the tab's session.
+func (t Tab) IsBusy() bool {
+	return isBusy(t.Messages)
+}
+
+// Tabs returns the open tabs as of the last SyncTab.
+func (a *App) Tabs() []Tab {
+	return slices.Clone(a.tabs)
+}
+
+// ActiveTab returns the index of the active tab.
+func (a *App) ActiveTab() int {
+	return a.activeTab
+}
+
+// IsReadOnly reports whether the active tab only shows a session, without
+// accepting prompts.
+func (a *App) IsReadOnly() bool {
+	return a.tabs[a.activeTab].ReadOnly
+}
+
+// SyncTab writes the state of the active tab, App.Session and App.Messages,
+// back to the tab list.
+func (a *App) SyncTab() {
+	a.tabs[a.activeTab].Session = a.Session
+	a.tabs[a.activeTab].Messages = a.Messages
+}
+
+func (a *App) activateTab(index int) {
+	a.activeTab = index
+	a.Session = a.tabs[index].Session
+	a.Messages = a.tabs[index].Messages
+}
+
+// OpenTab makes session the active tab, switching to it if it is already
+// open, taking over the active tab if that has no session yet, or opening a
+// new one.
+func (a *App) OpenTab(session *opencode.Session, messages []opencode.Message) {
+	a.SyncTab()
+	index := slices.IndexFunc(a.tabs, func(t Tab) bool { return t.Session.ID == session.ID })
+	switch {
+	case index >= 0:
+	case a.Session.ID == "":
+		index = a.activeTab
+	default:
+		a.tabs = append(a.tabs, Tab{})
+		index = len(a.tabs) - 1
+	}
+	a.tabs[index] = Tab{Session: session, Messages: messages}
+	a.activateTab(index)
+}
+
+// OpenReadOnlyTab makes session the active tab like OpenTab, marking it read
+// only.
+func (a *App) OpenReadOnlyTab(session *opencode.Session, messages []opencode.Message) {
+	a.OpenTab(session, messages)
+	a.tabs[a.activeTab].ReadOnly = true
+}
+
+// NewTab opens a tab without a session and makes it active. The active tab
+// is reused if it has no session yet.
+func (a *App) NewTab() {
+	a.SyncTab()
+	if a.Session.ID == "" {
+		return
+	}
+	a.tabs = append(a.tabs, emptyTab())
+	a.activateTab(len(a.tabs) - 1)
+}
+
+// SwitchTab activates the tab delta places away, wrapping around, and
+// reports whether the active tab changed.
+func (a *App) SwitchTab(delta int) bool {
+	a.SyncTab()
+	if len(a.tabs) < 2 {
+		return false
+	}
+	index := ((a.activeTab+delta)%len(a.tabs) + len(a.tabs)) % len(a.tabs)
+	a.activateTab(index)
+	return true
+}
+
+// CloseTab closes the active tab. Closing the last tab leaves an empty one.
+func (a *App) CloseTab() {
+	a.SyncTab()
+	a.closeTab(a.activeTab)
+}
+
+func (a *App) closeTab(index int) {
+	a.tabs = slices.Delete(a.tabs, index, index+1)
+	if len(a.tabs) == 0 {
+		a.tabs = []Tab{emptyTab()}
+	}
+	if a.activeTab > index || a.activeTab == len(a.tabs) {
+		a.activeTab--
+	}
+	a.activateTab(max(a.activeTab, 0))
+}
+
+// CloseSessionTab closes the tab of a session that no longer exists and
+// reports whether it was the active one.
+func (a *App) CloseSessionTab(sessionID string) bool {
+	a.SyncTab()
+	index := slices.IndexFunc(a.tabs, func(t Tab) bool { return t.Session.ID == sessionID })
+	if index < 0 {
+		return false
+	}
+	active := index == a.activeTab
+	a.closeTab(index)
+	return active
+}
+
+// UpdateSession replaces the session in whichever tab has it open.
+func (a *App) UpdateSession(session opencode.Session) {
+	if session.ID == a.Session.ID {
+		a.Session = &session
+		return
+	}
+	for i := range a.tabs {
+		if i != a.activeTab && a.tabs[i].Session.ID == session.ID {
+			a.tabs[i].Session = &session
+		}
+	}
+}
+
+// UpdateMessages replaces all messages of a session in whichever tab has it
+// open.
+func (a *App) UpdateMessages(session opencode.Session, messages []opencode.Message) {
+	a.UpdateSession(session)
+	if session.ID == a.Session.ID {
+		a.Messages = messages
+		return
+	}
+	for i := range a.tabs {
+		if i != a.activeTab && a.tabs[i].Session.ID == session.ID {
+			a.tabs[i].Messages = messages
+		}
+	}
+}
+
+// UpdateMessage adds or replaces a message in whichever tab has its session
+// open and reports whether that is the active tab.
+func (a *App) UpdateMessage(message opencode.Message) bool {
+	sessionID := message.Metadata.SessionID
+	if sessionID == a.Session.ID {
+		a.Messages = upsertMessage(a.Messages, message)
+		return true
+	}
+	for i := range a.tabs {
+		if i != a.activeTab && a.tabs[i].Session.ID == sessionID {
+			a.tabs[i].Messages = upsertMessage(a.tabs[i].Messages, message)
+		}
+	}
+	return false
+}
+
+// openSessionIDs lists the sessions open in any tab.
+func (a *App) openSessionIDs() []string {
+	a.SyncTab()
+	var ids []string
+	for _, tab := range a.tabs {
+		if tab.Session.ID != "" {
+			ids = append(ids, tab.Session.ID)
+		}
+	}
+	return ids
+}
+
+// upsertMessage replaces the message with the same ID, or the optimistic
+// message a user message was created from, or appends it.
+func upsertMessage(messages []opencode.Message, message opencode.Message) []opencode.Message {
+	if message.Role == opencode.MessageRoleUser {
+		for i, m := range messages {
+			if strings.HasPrefix(m.ID, "optimistic-") && m.Role == opencode.MessageRoleUser {
+				messages[i] = message
+				return messages
+			}
+		}
+	}
+	for i, m := range messages {
+		if m.ID == message.ID {
+			messages[i] = message
+			return messages
+		}
+	}
+	return append(messages, message)
+}
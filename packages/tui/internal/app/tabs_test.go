@@ -0,0 +1,90 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+// tabTitles lists the titles of the open tabs, the active one in brackets.
+func tabTitles(app *App) []string {
+	var titles []string
+	for i, tab := range app.Tabs() {
+		title := tab.Title()
+		if i == app.ActiveTab() {
+			title = "[" + title + "]"
+		}
+		titles = append(titles, title)
+	}
+	return titles
+}
+
+func TestTabs(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	first := testserver.NewSession("ses_1", "First")
+	second := testserver.NewSession("ses_2", "Second")
+	third := testserver.NewSession("ses_3", "Third")
+
+	for _, step := range []struct {
+		name string
+		do   func()
+		want []string
+	}{
+		{name: "start", do: func() {}, want: []string{"[New session]"}},
+		{name: "open takes over the empty tab", do: func() { app.OpenTab(&first, nil) }, want: []string{"[First]"}},
+		{name: "open another", do: func() { app.OpenTab(&second, nil) }, want: []string{"First", "[Second]"}},
+		{name: "open an open one", do: func() { app.OpenTab(&first, nil) }, want: []string{"[First]", "Second"}},
+		{name: "new", do: app.NewTab, want: []string{"First", "Second", "[New session]"}},
+		{name: "new reuses the empty tab", do: app.NewTab, want: []string{"First", "Second", "[New session]"}},
+		{name: "switch wraps around", do: func() { app.SwitchTab(1) }, want: []string{"[First]", "Second", "New session"}},
+		{name: "switch back", do: func() { app.SwitchTab(-1) }, want: []string{"First", "Second", "[New session]"}},
+		{name: "read only", do: func() { app.OpenReadOnlyTab(&third, nil) }, want: []string{"First", "Second", "[Third]"}},
+		{name: "close", do: app.CloseTab, want: []string{"First", "[Second]"}},
+		{name: "close another session", do: func() { app.CloseSessionTab(first.ID) }, want: []string{"[Second]"}},
+		{name: "close the last", do: app.CloseTab, want: []string{"[New session]"}},
+	} {
+		step.do()
+		if got := tabTitles(app); !slices.Equal(got, step.want) {
+			t.Fatalf("%s: tabs are %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestTabsIsARead(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	first := testserver.NewSession("ses_1", "First")
+	app.OpenTab(&first, nil)
+
+	// the session is renamed and a message arrives, as in an update
+	renamed := testserver.NewSession("ses_1", "Renamed")
+	app.Session = &renamed
+	app.Messages = []opencode.Message{testserver.UserMessage("msg_1", first.ID, "Hello")}
+	if got := app.Tabs()[0]; got.Title() != "First" || len(got.Messages) != 0 {
+		t.Errorf("tabs changed before they were synced: %q with %d messages", got.Title(), len(got.Messages))
+	}
+	app.SyncTab()
+	if got := app.Tabs()[0]; got.Title() != "Renamed" || len(got.Messages) != 1 {
+		t.Errorf("tabs are not synced: %q with %d messages", got.Title(), len(got.Messages))
+	}
+}
+
+func TestUpdateMessageInBackgroundTab(t *testing.T) {
+	app := newTestApp(t, testserver.New(t))
+	first := testserver.NewSession("ses_1", "First")
+	second := testserver.NewSession("ses_2", "Second")
+	app.OpenTab(&first, nil)
+	app.OpenTab(&second, nil)
+
+	if app.UpdateMessage(testserver.AssistantMessage("msg_1", first.ID, "", false)) {
+		t.Error("a message of a background tab was reported as active")
+	}
+	if !app.Tabs()[0].IsBusy() {
+		t.Error("background tab is not busy with the streaming reply")
+	}
+	app.UpdateMessage(testserver.AssistantMessage("msg_1", first.ID, "Done", true))
+	if tab := app.Tabs()[0]; tab.IsBusy() || len(tab.Messages) != 1 {
+		t.Errorf("background tab has %d messages, busy %v, want the one completed reply", len(tab.Messages), tab.IsBusy())
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/util"
+)
+
+// TaskSessionOpenedMsg carries the child session a task ran in, to be shown
+// read only.
+type TaskSessionOpenedMsg struct {
+	Session  opencode.Session
+	Messages []opencode.Message
+}
+
+// TaskSessionID returns the child session the task tool call ran in, or
+// the first task of the message when toolCallID is empty. It is empty for
+// tasks that haven't started or were run by servers that don't record it.
+func TaskSessionID(message opencode.Message, toolCallID string) string {
+	for _, part := range message.Parts {
+		invocation, ok := part.AsUnion().(opencode.ToolInvocationPart)
+		if !ok || invocation.ToolInvocation.ToolName != "task" {
+			continue
+		}
+		if toolCallID != "" && invocation.ToolInvocation.ToolCallID != toolCallID {
+			continue
+		}
+		metadata := message.Metadata.Tool[invocation.ToolInvocation.ToolCallID]
+		if sessionID, ok := metadata.ExtraFields["sessionID"].(string); ok && sessionID != "" {
+			return sessionID
+		}
+	}
+	return ""
+}
+
+// OpenTaskSession loads a subagent session with its messages.
+func (a *App) OpenTaskSession(ctx context.Context, sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		sessions, err := a.ListSessions(ctx)
+		if err != nil {
+			slog.Error("Failed to list sessions", "error", err)
+			return toast.NewErrorToast("Failed to open task: " + err.Error())()
+		}
+		for _, session := range sessions {
+			if session.ID != sessionID {
+				continue
+			}
+			messages, err := a.ListMessages(ctx, sessionID)
+			if err != nil {
+				slog.Error("Failed to list messages", "error", err)
+				return toast.NewErrorToast("Failed to open task: " + err.Error())()
+			}
+			return TaskSessionOpenedMsg{Session: session, Messages: messages}
+		}
+		return toast.NewErrorToast("The task's session no longer exists")()
+	}
+}
+
+// SelectSession opens a session picked from the session list, read only
+// when it is the session of a task.
+func (a *App) SelectSession(ctx context.Context, session opencode.Session) tea.Cmd {
+	if session.ParentID == "" {
+		return util.CmdHandler(SessionSelectedMsg(&session))
+	}
+	return func() tea.Msg {
+		messages, err := a.ListMessages(ctx, session.ID)
+		if err != nil {
+			slog.Error("Failed to list messages", "error", err)
+			return toast.NewErrorToast("Failed to open session: " + err.Error())()
+		}
+		return TaskSessionOpenedMsg{Session: session, Messages: messages}
+	}
+}
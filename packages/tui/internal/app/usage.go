@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+)
+
+// TokenUsage counts tokens by kind.
+type TokenUsage struct {
+	Input      float64
+	Output     float64
+	Reasoning  float64
+	CacheRead  float64
+	CacheWrite float64
+}
+
+func (t *TokenUsage) add(other TokenUsage) {
+	t.Input += other.Input
+	t.Output += other.Output
+	t.Reasoning += other.Reasoning
+	t.CacheRead += other.CacheRead
+	t.CacheWrite += other.CacheWrite
+}
+
+// CacheHitRatio is the share of prompt tokens that were read from the cache.
+func (t TokenUsage) CacheHitRatio() float64 {
+	prompt := t.Input + t.CacheRead + t.CacheWrite
+	if prompt == 0 {
+		return 0
+	}
+	return t.CacheRead / prompt
+}
+
+// TurnUsage is what one reply of the agent used.
+type TurnUsage struct {
+	MessageID string
+	Model     string
+	Tokens    TokenUsage
+	Cost      float64
+	// Context is the size of the context after the turn, in tokens.
+	Context float64
+}
+
+// ModelUsage is what the replies of one model used.
+type ModelUsage struct {
+	Model  string
+	Turns  int
+	Tokens TokenUsage
+	Cost   float64
+}
+
+// Usage sums up the tokens and cost of replies, in total and by model.
+type Usage struct {
+	Sessions int
+	Turns    []TurnUsage
+	Models   []ModelUsage
+	Tokens   TokenUsage
+	Cost     float64
+}
+
+// ProjectUsageMsg carries the usage of every session of the project.
+type ProjectUsageMsg struct {
+	Usage Usage
+	Err   error
+}
+
+// contextTokens is the size of the context after an assistant message, if it
+// reports its usage.
+func contextTokens(message opencode.Message) (float64, bool) {
+	usage := message.Metadata.Assistant.Tokens
+	if usage.Output <= 0 {
+		return 0, false
+	}
+	if message.Metadata.Assistant.Summary {
+		return usage.Output, true
+	}
+	return usage.Input +
+		usage.Cache.Write +
+		usage.Cache.Read +
+		usage.Output +
+		usage.Reasoning, true
+}
+
+// add counts the replies among messages, which belong to one session.
+func (u *Usage) add(messages []opencode.Message) {
+	u.Sessions++
+	size := 0.0
+	for _, message := range messages {
+		if message.Role != opencode.MessageRoleAssistant {
+			continue
+		}
+		assistant := message.Metadata.Assistant
+		if tokens, ok := contextTokens(message); ok {
+			size = tokens
+		}
+		turn := TurnUsage{
+			MessageID: message.ID,
+			Model:     assistant.ModelID,
+			Tokens: TokenUsage{
+				Input:      assistant.Tokens.Input,
+				Output:     assistant.Tokens.Output,
+				Reasoning:  assistant.Tokens.Reasoning,
+				CacheRead:  assistant.Tokens.Cache.Read,
+				CacheWrite: assistant.Tokens.Cache.Write,
+			},
+			Cost:    assistant.Cost,
+			Context: size,
+		}
+		u.Turns = append(u.Turns, turn)
+		u.Tokens.add(turn.Tokens)
+		u.Cost += turn.Cost
+
+		i := slices.IndexFunc(u.Models, func(m ModelUsage) bool { return m.Model == turn.Model })
+		if i < 0 {
+			i = len(u.Models)
+			u.Models = append(u.Models, ModelUsage{Model: turn.Model})
+		}
+		u.Models[i].Turns++
+		u.Models[i].Tokens.add(turn.Tokens)
+		u.Models[i].Cost += turn.Cost
+	}
+}
+
+// SessionUsage breaks down the usage of the current session by reply.
+func (a *App) SessionUsage() Usage {
+	var usage Usage
+	usage.add(a.Messages)
+	return usage
+}
+
+// ProjectUsage loads the messages of every session of the project and sums
+// up their usage.
+func (a *App) ProjectUsage(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		sessions, err := a.ListSessions(ctx)
+		if err != nil {
+			slog.Error("Failed to list sessions", "error", err)
+			return ProjectUsageMsg{Err: err}
+		}
+		var usage Usage
+		for _, session := range sessions {
+			messages, err := a.ListMessages(ctx, session.ID)
+			if err != nil {
+				slog.Error("Failed to load messages", "session", session.ID, "error", err)
+				return ProjectUsageMsg{Err: err}
+			}
+			usage.add(messages)
+		}
+		slices.SortStableFunc(usage.Models, func(a, b ModelUsage) int {
+			switch {
+			case a.Cost > b.Cost:
+				return -1
+			case a.Cost < b.Cost:
+				return 1
+			}
+			return 0
+		})
+		return ProjectUsageMsg{Usage: usage}
+	}
+}
@@ -30,6 +30,11 @@ type Command struct {
 	Description string
 	Keybindings []Keybinding
 	Trigger     string
+	// Template is the prompt a custom command sends
+	Template string
+	// Arguments is what followed the trigger when the command was typed in
+	// the editor
+	Arguments string
 }
 
 func (c Command) Keys() []string {
@@ -59,10 +64,20 @@ func (r CommandRegistry) Sorted() []Command {
 	return commands
 }
 
+// ByTrigger returns the command run by typing /trigger.
+func (r CommandRegistry) ByTrigger(trigger string) (Command, bool) {
+	for _, command := range r {
+		if command.Trigger != "" && command.Trigger == trigger {
+			return command, true
+		}
+	}
+	return Command{}, false
+}
+
 func (r CommandRegistry) Matches(msg tea.KeyPressMsg, leader bool) []Command {
 	var matched []Command
 	for _, command := range r.Sorted() {
-		if command.Matches(msg, leader) {
+		if !editorCommands[command.Name] && command.Matches(msg, leader) {
 			matched = append(matched, command)
 		}
 	}
@@ -77,6 +92,17 @@ const (
 	SessionShareCommand         CommandName = "session_share"
 	SessionInterruptCommand     CommandName = "session_interrupt"
 	SessionCompactCommand       CommandName = "session_compact"
+	SessionQueueCommand         CommandName = "session_queue"
+	SessionExportCommand        CommandName = "session_export"
+	SessionImportCommand        CommandName = "session_import"
+	SessionUsageCommand         CommandName = "session_usage"
+	SessionRetryCommand         CommandName = "session_retry"
+	SessionRetryModelCommand    CommandName = "session_retry_model"
+	SessionContinueCommand      CommandName = "session_continue"
+	ShellClearCommand           CommandName = "shell_clear"
+	TabNextCommand              CommandName = "tab_next"
+	TabPreviousCommand          CommandName = "tab_previous"
+	TabCloseCommand             CommandName = "tab_close"
 	ToolDetailsCommand          CommandName = "tool_details"
 	ModelListCommand            CommandName = "model_list"
 	ThemeListCommand            CommandName = "theme_list"
@@ -88,7 +114,10 @@ const (
 	InputClearCommand           CommandName = "input_clear"
 	InputPasteCommand           CommandName = "input_paste"
 	InputSubmitCommand          CommandName = "input_submit"
+	InputHistoryCommand         CommandName = "input_history"
 	InputNewlineCommand         CommandName = "input_newline"
+	HistoryPreviousCommand      CommandName = "history_previous"
+	HistoryNextCommand          CommandName = "history_next"
 	MessagesPageUpCommand       CommandName = "messages_page_up"
 	MessagesPageDownCommand     CommandName = "messages_page_down"
 	MessagesHalfPageUpCommand   CommandName = "messages_half_page_up"
@@ -100,9 +129,36 @@ const (
 	MessagesLayoutToggleCommand CommandName = "messages_layout_toggle"
 	MessagesCopyCommand         CommandName = "messages_copy"
 	MessagesRevertCommand       CommandName = "messages_revert"
+	MessagesRedoCommand         CommandName = "messages_redo"
+	MessagesForkCommand         CommandName = "messages_fork"
+	MessagesOpenTaskCommand     CommandName = "messages_open_task"
 	AppExitCommand              CommandName = "app_exit"
 )
 
+// argumentCommands are the built-in commands that make use of arguments,
+// skipping their dialog.
+var argumentCommands = map[CommandName]bool{
+	SessionListCommand:   true,
+	SessionExportCommand: true,
+	SessionImportCommand: true,
+	SessionRetryCommand:  true,
+	ModelListCommand:     true,
+	ThemeListCommand:     true,
+}
+
+// editorCommands are bound to keys the editor handles itself, since they
+// only apply at some cursor positions.
+var editorCommands = map[CommandName]bool{
+	HistoryPreviousCommand: true,
+	HistoryNextCommand:     true,
+}
+
+// TakesArguments reports whether the command does something with the text
+// typed after its trigger.
+func (c Command) TakesArguments() bool {
+	return argumentCommands[c.Name] || c.IsCustom()
+}
+
 func (k Command) Matches(msg tea.KeyPressMsg, leader bool) bool {
 	for _, binding := range k.Keybindings {
 		if binding.Matches(msg, leader) {
@@ -171,6 +227,64 @@ func LoadFromConfig(config *opencode.Config) CommandRegistry {
 			Keybindings: parseBindings("<leader>c"),
 			Trigger:     "compact",
 		},
+		{
+			Name:        SessionQueueCommand,
+			Description: "edit queued prompts",
+			Keybindings: parseBindings("<leader>w"),
+			Trigger:     "queue",
+		},
+		{
+			Name:        SessionExportCommand,
+			Description: "export session",
+			Trigger:     "export",
+		},
+		{
+			Name:        SessionImportCommand,
+			Description: "import session",
+			Trigger:     "import",
+		},
+		{
+			Name:        SessionUsageCommand,
+			Description: "token and cost usage",
+			Trigger:     "usage",
+		},
+		{
+			Name:        SessionRetryCommand,
+			Description: "retry last prompt",
+			Keybindings: parseBindings("<leader>a"),
+			Trigger:     "retry",
+		},
+		{
+			Name:        SessionRetryModelCommand,
+			Description: "retry with another model",
+			Keybindings: parseBindings("<leader>b"),
+		},
+		{
+			Name:        SessionContinueCommand,
+			Description: "continue cut off reply",
+			Keybindings: parseBindings("<leader>k"),
+			Trigger:     "continue",
+		},
+		{
+			Name:        ShellClearCommand,
+			Description: "discard shell output",
+			Trigger:     "discard",
+		},
+		{
+			Name:        TabNextCommand,
+			Description: "next tab",
+			Keybindings: parseBindings("<leader>right"),
+		},
+		{
+			Name:        TabPreviousCommand,
+			Description: "previous tab",
+			Keybindings: parseBindings("<leader>left"),
+		},
+		{
+			Name:        TabCloseCommand,
+			Description: "close tab",
+			Keybindings: parseBindings("<leader>x"),
+		},
 		{
 			Name:        ToolDetailsCommand,
 			Description: "toggle tool details",
@@ -231,11 +345,26 @@ func LoadFromConfig(config *opencode.Config) CommandRegistry {
 			Description: "submit message",
 			Keybindings: parseBindings("enter"),
 		},
+		{
+			Name:        InputHistoryCommand,
+			Description: "search prompt history",
+			Keybindings: parseBindings("ctrl+r"),
+		},
 		{
 			Name:        InputNewlineCommand,
 			Description: "insert newline",
 			Keybindings: parseBindings("shift+enter", "ctrl+j"),
 		},
+		{
+			Name:        HistoryPreviousCommand,
+			Description: "previous prompt",
+			Keybindings: parseBindings("up"),
+		},
+		{
+			Name:        HistoryNextCommand,
+			Description: "next prompt",
+			Keybindings: parseBindings("down"),
+		},
 		{
 			Name:        MessagesPageUpCommand,
 			Description: "page up",
@@ -291,6 +420,21 @@ func LoadFromConfig(config *opencode.Config) CommandRegistry {
 			Description: "revert message",
 			Keybindings: parseBindings("<leader>u"),
 		},
+		{
+			Name:        MessagesRedoCommand,
+			Description: "redo reverted messages",
+			Keybindings: parseBindings("<leader>r"),
+		},
+		{
+			Name:        MessagesForkCommand,
+			Description: "fork session from message",
+			Keybindings: parseBindings("<leader>o"),
+		},
+		{
+			Name:        MessagesOpenTaskCommand,
+			Description: "open task session",
+			Keybindings: parseBindings("<leader>g"),
+		},
 		{
 			Name:        AppExitCommand,
 			Description: "exit the app",
@@ -300,7 +444,11 @@ func LoadFromConfig(config *opencode.Config) CommandRegistry {
 	}
 	registry := make(CommandRegistry)
 	keybinds := map[string]string{}
-	marshalled, _ := json.Marshal(config.Keybinds)
+	// the raw config also holds keybinds the SDK has no field for yet
+	marshalled := []byte(config.Keybinds.JSON.RawJSON())
+	if len(marshalled) == 0 {
+		marshalled, _ = json.Marshal(config.Keybinds)
+	}
 	json.Unmarshal(marshalled, &keybinds)
 	for _, command := range defaults {
 		if keybind, ok := keybinds[string(command.Name)]; ok && keybind != "" {
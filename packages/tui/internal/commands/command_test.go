@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"encoding/json"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+)
+
+func TestLoadFromConfig(t *testing.T) {
+	var config opencode.Config
+	keybinds := `{"keybinds": {"session_new": "<leader>N", "messages_redo": "ctrl+y", "history_previous": "ctrl+p"}}`
+	if err := json.Unmarshal([]byte(keybinds), &config); err != nil {
+		t.Fatal(err)
+	}
+	registry := LoadFromConfig(&config)
+
+	for name, want := range map[CommandName]Keybinding{
+		SessionNewCommand:      {RequiresLeader: true, Key: "N"},
+		MessagesRedoCommand:    {Key: "ctrl+y"},
+		AppHelpCommand:         {RequiresLeader: true, Key: "h"},
+		HistoryPreviousCommand: {Key: "ctrl+p"},
+	} {
+		if got := registry[name].Keybindings; len(got) != 1 || got[0] != want {
+			t.Errorf("%s is bound to %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestMatchesSkipsEditorCommands(t *testing.T) {
+	registry := LoadFromConfig(&opencode.Config{})
+	if matches := registry.Matches(tea.KeyPressMsg{Code: tea.KeyUp}, false); len(matches) != 0 {
+		t.Errorf("up matched %+v, want it left to the editor", matches)
+	}
+	if !registry[HistoryPreviousCommand].Matches(tea.KeyPressMsg{Code: tea.KeyUp}, false) {
+		t.Error("up is not bound to the previous prompt")
+	}
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// customPrefix marks the names of commands loaded from Markdown files.
+const customPrefix = "custom_"
+
+// IsCustom reports whether the command sends a prompt from a template
+// rather than running a built-in action.
+func (c Command) IsCustom() bool {
+	return strings.HasPrefix(string(c.Name), customPrefix)
+}
+
+// LoadCustomCommands reads prompt commands from the Markdown files in dirs.
+// A file may start with front matter giving a description and a trigger,
+// the trigger defaulting to the file name; the rest of it is the prompt
+// template. Commands in later directories replace those in earlier ones with
+// the same trigger.
+func LoadCustomCommands(dirs ...string) []Command {
+	var custom []Command
+	index := map[string]int{}
+	for _, dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err != nil {
+			continue
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				slog.Warn("Failed to read custom command", "path", path, "error", err)
+				continue
+			}
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			command, ok := parseCustomCommand(name, string(data))
+			if !ok {
+				slog.Warn("Skipping custom command without a prompt", "path", path)
+				continue
+			}
+			if i, ok := index[command.Trigger]; ok {
+				custom[i] = command
+				continue
+			}
+			index[command.Trigger] = len(custom)
+			custom = append(custom, command)
+		}
+	}
+	return custom
+}
+
+func parseCustomCommand(name string, data string) (Command, bool) {
+	command := Command{
+		Trigger:     name,
+		Description: "custom command",
+	}
+	body := strings.ReplaceAll(data, "\r\n", "\n")
+	if rest, ok := strings.CutPrefix(body, "---\n"); ok {
+		if frontMatter, template, ok := strings.Cut(rest, "\n---"); ok {
+			body = strings.TrimPrefix(template, "\n")
+			for line := range strings.SplitSeq(frontMatter, "\n") {
+				key, value, ok := strings.Cut(line, ":")
+				if !ok {
+					continue
+				}
+				value = strings.TrimSpace(value)
+				if unquoted, err := strconv.Unquote(value); err == nil {
+					value = unquoted
+				} else {
+					value = strings.Trim(value, "'")
+				}
+				switch strings.TrimSpace(key) {
+				case "description":
+					command.Description = value
+				case "trigger":
+					command.Trigger = strings.TrimPrefix(value, "/")
+				}
+			}
+		}
+	}
+	command.Template = strings.TrimSpace(body)
+	command.Name = CommandName(customPrefix + command.Trigger)
+	return command, command.Trigger != "" && command.Template != ""
+}
+
+// AddCustom registers custom commands, keeping the built-in ones whose
+// trigger they would take.
+func (r CommandRegistry) AddCustom(custom []Command) {
+	triggers := map[string]bool{}
+	for _, command := range r {
+		if command.Trigger != "" && !command.IsCustom() {
+			triggers[command.Trigger] = true
+		}
+	}
+	for _, command := range custom {
+		if triggers[command.Trigger] {
+			slog.Warn("Custom command shadows a built-in command", "trigger", command.Trigger)
+			continue
+		}
+		r[command.Name] = command
+	}
+}
+
+// Expand fills in the template of a custom command, replacing $ARGUMENTS
+// with the given arguments.
+func (c Command) Expand(arguments string) string {
+	return strings.TrimSpace(strings.ReplaceAll(c.Template, "$ARGUMENTS", arguments))
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCommand(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadCustomCommands(t *testing.T) {
+	user := t.TempDir()
+	project := t.TempDir()
+	writeCommand(t, user, "review.md", "Review the staged changes.")
+	writeCommand(t, user, "explain.md", "---\ndescription: explain a file\ntrigger: \"what\"\n---\nExplain @$ARGUMENTS\n")
+	writeCommand(t, user, "empty.md", "---\ndescription: nothing to send\n---\n")
+	writeCommand(t, project, "review.md", "---\ndescription: project review\n---\nReview against CONTRIBUTING.md.")
+
+	custom := LoadCustomCommands(user, project, filepath.Join(project, "missing"))
+	byTrigger := map[string]Command{}
+	for _, command := range custom {
+		byTrigger[command.Trigger] = command
+	}
+	if len(custom) != 2 {
+		t.Fatalf("loaded %d commands, want 2: %+v", len(custom), custom)
+	}
+
+	what := byTrigger["what"]
+	if what.Description != "explain a file" || what.Name != "custom_what" || !what.IsCustom() {
+		t.Errorf("front matter not applied: %+v", what)
+	}
+	if got := what.Expand("main.go"); got != "Explain @main.go" {
+		t.Errorf("expanded to %q", got)
+	}
+	if review := byTrigger["review"]; review.Template != "Review against CONTRIBUTING.md." {
+		t.Errorf("project command does not replace the user one: %+v", review)
+	}
+
+	registry := CommandRegistry{AppHelpCommand: {Name: AppHelpCommand, Trigger: "help"}}
+	registry.AddCustom(append(custom, Command{Name: "custom_help", Trigger: "help", Template: "help me"}))
+	if _, ok := registry["custom_help"]; ok {
+		t.Error("custom command replaced a built-in trigger")
+	}
+	if _, ok := registry["custom_review"]; !ok {
+		t.Error("custom command was not registered")
+	}
+}
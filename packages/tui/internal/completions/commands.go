@@ -1,6 +1,7 @@
 package completions
 
 import (
+	"context"
 	"sort"
 	"strings"
 
@@ -39,7 +40,18 @@ func getCommandCompletionItem(cmd commands.Command, space int, t theme.Theme) di
 	})
 }
 
+// TakesArguments reports whether the command with the trigger takes
+// arguments.
+func (c *CommandCompletionProvider) TakesArguments(trigger string) bool {
+	command, ok := c.app.Commands.ByTrigger(trigger)
+	return ok && command.TakesArguments()
+}
+
 func (c *CommandCompletionProvider) GetChildEntries(query string) ([]dialog.CompletionItemI, error) {
+	if trigger, arguments, ok := strings.Cut(query, " "); ok {
+		return c.getArgumentEntries(trigger, arguments), nil
+	}
+
 	t := theme.CurrentTheme()
 	commands := c.app.Commands
 
@@ -93,3 +105,72 @@ func (c *CommandCompletionProvider) GetChildEntries(query string) ([]dialog.Comp
 	}
 	return items, nil
 }
+
+// getArgumentEntries suggests arguments for the command with the trigger,
+// followed by the arguments as typed so far.
+func (c *CommandCompletionProvider) getArgumentEntries(trigger string, arguments string) []dialog.CompletionItemI {
+	t := theme.CurrentTheme()
+	command, ok := c.app.Commands.ByTrigger(trigger)
+	if !ok {
+		return []dialog.CompletionItemI{}
+	}
+	arguments = strings.TrimSpace(arguments)
+	item := func(argument string, description string) dialog.CompletionItemI {
+		title := "  /" + strings.TrimSpace(command.Trigger+" "+argument)
+		if description != "" {
+			title += styles.NewStyle().Foreground(t.TextMuted()).Render("  " + description)
+		}
+		return dialog.NewCompletionItem(dialog.CompletionItem{
+			Title: title,
+			Value: strings.TrimSpace(string(command.Name) + " " + argument),
+		})
+	}
+
+	suggestions := c.argumentSuggestions(command)
+	items := []dialog.CompletionItemI{}
+	exact := false
+	if arguments == "" {
+		for _, suggestion := range suggestions {
+			items = append(items, item(suggestion, ""))
+		}
+	} else {
+		matches := fuzzy.RankFindFold(arguments, suggestions)
+		sort.Sort(matches)
+		for _, match := range matches {
+			items = append(items, item(match.Target, ""))
+			exact = exact || match.Target == arguments
+		}
+	}
+	if !exact && (arguments != "" || len(items) == 0) {
+		items = append(items, item(arguments, command.Description))
+	}
+	return items
+}
+
+// argumentSuggestions lists the arguments known for a command.
+func (c *CommandCompletionProvider) argumentSuggestions(command commands.Command) []string {
+	switch command.Name {
+	case commands.ModelListCommand, commands.SessionRetryCommand:
+		providers, err := c.app.ListProviders(context.Background())
+		if err != nil {
+			return nil
+		}
+		var models []string
+		for _, provider := range providers {
+			for id := range provider.Models {
+				models = append(models, provider.ID+"/"+id)
+			}
+		}
+		sort.Strings(models)
+		return models
+	case commands.ThemeListCommand:
+		return theme.AvailableThemes()
+	case commands.SessionExportCommand:
+		var formats []string
+		for _, format := range app.ExportFormats {
+			formats = append(formats, string(format))
+		}
+		return formats
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/v2/spinner"
 	tea "github.com/charmbracelet/bubbletea/v2"
@@ -24,6 +25,8 @@ type EditorComponent interface {
 	Content(width int) string
 	Lines() int
 	Value() string
+	Attachments() []app.Attachment
+	SetValue(value string, attachments []app.Attachment)
 	Focused() bool
 	Focus() (tea.Model, tea.Cmd)
 	Blur()
@@ -32,6 +35,7 @@ type EditorComponent interface {
 	Paste() (tea.Model, tea.Cmd)
 	Newline() (tea.Model, tea.Cmd)
 	SetInterruptKeyInDebounce(inDebounce bool)
+	SaveDraft()
 }
 
 type editorComponent struct {
@@ -40,6 +44,17 @@ type editorComponent struct {
 	attachments            []app.Attachment
 	spinner                spinner.Model
 	interruptKeyInDebounce bool
+	historyIndex           int    // -1 unless a prompt from the history is shown
+	historyDraft           string // what was typed before browsing the history
+	draftSession           string // the session the prompt is written in
+	draftGeneration        int
+}
+
+// draftSaveDelay is how long after the last change the draft is saved.
+const draftSaveDelay = time.Second
+
+type draftSaveMsg struct {
+	generation int
 }
 
 func (m *editorComponent) Init() tea.Cmd {
@@ -47,6 +62,25 @@ func (m *editorComponent) Init() tea.Cmd {
 }
 
 func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case draftSaveMsg:
+		if msg.generation == m.draftGeneration {
+			m.SaveDraft()
+		}
+		return m, nil
+	case app.SessionLoadedMsg, app.SessionClearedMsg:
+		m.switchDraft()
+	}
+
+	value, attachments := m.Value(), len(m.attachments)
+	model, cmd := m.update(msg)
+	if m.Value() != value || len(m.attachments) != attachments {
+		cmd = tea.Batch(cmd, m.scheduleDraftSave())
+	}
+	return model, cmd
+}
+
+func (m *editorComponent) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
@@ -54,25 +88,43 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case spinner.TickMsg:
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
+	case tea.PasteMsg:
+		if m.app.IsCompacting() || m.app.IsReadOnly() {
+			return m, nil
+		}
 	case tea.KeyPressMsg:
+		// The editor is locked until the session has been compacted, and in
+		// subagent sessions
+		if m.app.IsCompacting() || m.app.IsReadOnly() {
+			return m, nil
+		}
 		// Maximize editor responsiveness for printable characters
 		if msg.Text != "" {
 			m.textarea, cmd = m.textarea.Update(msg)
 			cmds = append(cmds, cmd)
 			return m, tea.Batch(cmds...)
 		}
+		// Up on the first line and down on the last browse the history
+		switch {
+		case m.app.Commands[commands.HistoryPreviousCommand].Matches(msg, false):
+			if m.onFirstLine() && m.recallHistory(-1) {
+				return m, nil
+			}
+		case m.app.Commands[commands.HistoryNextCommand].Matches(msg, false):
+			if m.onLastLine() && m.recallHistory(1) {
+				return m, nil
+			}
+		}
 	case dialog.ThemeSelectedMsg:
 		m.textarea = createTextArea(&m.textarea)
 		m.spinner = createSpinner()
 		return m, tea.Batch(m.spinner.Tick, m.textarea.Focus())
 	case dialog.CompletionSelectedMsg:
 		if msg.IsCommand {
-			commandName := strings.TrimPrefix(msg.CompletionValue, "/")
-			updated, cmd := m.Clear()
-			m = updated.(*editorComponent)
-			cmds = append(cmds, cmd)
-			cmds = append(cmds, util.CmdHandler(commands.ExecuteCommandMsg(m.app.Commands[commands.CommandName(commandName)])))
-			return m, tea.Batch(cmds...)
+			commandName, arguments, _ := strings.Cut(strings.TrimPrefix(msg.CompletionValue, "/"), " ")
+			command := m.app.Commands[commands.CommandName(commandName)]
+			command.Arguments = arguments
+			return m.runCommand(command)
 		} else {
 			existingValue := m.textarea.Value()
 
@@ -133,6 +185,19 @@ func (m *editorComponent) Content(width int) string {
 			hint = muted("working") + m.spinner.View() + muted("  ") + base(keyText) + muted(" interrupt")
 		}
 	}
+	if m.app.IsCompacting() {
+		hint = muted("compacting") + m.spinner.View() + muted("  input is locked until the summary is ready")
+	}
+	if m.app.IsReadOnly() {
+		hint = muted("read only")
+		if key := m.app.KeyBinding(commands.TabCloseCommand); key != "" {
+			hint += muted("  ") + base(key) + muted(" close")
+		}
+	}
+
+	for _, attachment := range m.attachments {
+		hint += muted("[" + attachment.FileName + "] ")
+	}
 
 	model := ""
 	if m.app.Model != nil {
@@ -183,6 +248,82 @@ func (m *editorComponent) Value() string {
 	return m.textarea.Value()
 }
 
+func (m *editorComponent) Attachments() []app.Attachment {
+	return m.attachments
+}
+
+func (m *editorComponent) SetValue(value string, attachments []app.Attachment) {
+	m.textarea.SetValue(value)
+	m.attachments = attachments
+	m.historyIndex = -1
+}
+
+// SaveDraft keeps the prompt being written so that it can be picked up
+// again after a restart.
+func (m *editorComponent) SaveDraft() {
+	m.app.SaveDraft(m.draftSession, app.Draft{Text: m.Value(), Attachments: m.attachments})
+}
+
+func (m *editorComponent) scheduleDraftSave() tea.Cmd {
+	m.draftGeneration++
+	generation := m.draftGeneration
+	return tea.Tick(draftSaveDelay, func(time.Time) tea.Msg {
+		return draftSaveMsg{generation: generation}
+	})
+}
+
+// switchDraft keeps the prompt written in the session that was left and
+// brings back the one left in the current session.
+func (m *editorComponent) switchDraft() {
+	if m.app.Session.ID == m.draftSession {
+		return
+	}
+	m.SaveDraft()
+	m.draftSession = m.app.Session.ID
+	m.draftGeneration++
+	m.loadDraft()
+}
+
+func (m *editorComponent) loadDraft() {
+	draft, _ := m.app.LoadDraft(m.draftSession)
+	m.SetValue(draft.Text, draft.Attachments)
+}
+
+func (m *editorComponent) onFirstLine() bool {
+	return m.textarea.Line() == 0 && m.textarea.LineInfo().RowOffset == 0
+}
+
+func (m *editorComponent) onLastLine() bool {
+	info := m.textarea.LineInfo()
+	return m.textarea.Line() == strings.Count(m.textarea.Value(), "\n") && info.RowOffset+1 >= info.Height
+}
+
+// recallHistory shows the prompt step entries older (negative) or newer than
+// the one shown, and what was typed before once past the newest. It returns
+// false when there is nothing further to show.
+func (m *editorComponent) recallHistory(step int) bool {
+	history := m.app.History()
+	index := m.historyIndex
+	if index < 0 {
+		index = len(history)
+	}
+	index += step
+	if index < 0 || index > len(history) {
+		return false
+	}
+	if m.historyIndex < 0 {
+		m.historyDraft = m.textarea.Value()
+	}
+	if index == len(history) {
+		m.textarea.SetValue(m.historyDraft)
+		m.historyIndex = -1
+	} else {
+		m.textarea.SetValue(history[index])
+		m.historyIndex = index
+	}
+	return true
+}
+
 func (m *editorComponent) Submit() (tea.Model, tea.Cmd) {
 	value := strings.TrimSpace(m.Value())
 	if value == "" {
@@ -194,20 +335,54 @@ func (m *editorComponent) Submit() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	var cmds []tea.Cmd
-	updated, cmd := m.Clear()
-	m = updated.(*editorComponent)
-	cmds = append(cmds, cmd)
-
 	attachments := m.attachments
-	m.attachments = nil
+	m.app.AddToHistory(value)
 
-	cmds = append(cmds, util.CmdHandler(app.SendMsg{Text: value, Attachments: attachments}))
-	return m, tea.Batch(cmds...)
+	// !command runs the command locally, its output and the attachments go
+	// with the next prompt
+	if command, ok := strings.CutPrefix(value, "!"); ok && strings.TrimSpace(command) != "" {
+		updated, cmd := m.clearText()
+		return updated, tea.Batch(cmd, util.CmdHandler(app.ShellRequestedMsg{Command: strings.TrimSpace(command)}))
+	}
+
+	// /trigger runs the command, with what follows as its arguments
+	if strings.HasPrefix(value, "/") {
+		trigger, arguments, _ := strings.Cut(value[1:], " ")
+		if command, ok := m.app.Commands.ByTrigger(trigger); ok {
+			command.Arguments = strings.TrimSpace(arguments)
+			return m.runCommand(command)
+		}
+	}
+
+	updated, cmd := m.Clear()
+	return updated, tea.Batch(cmd, util.CmdHandler(app.SendMsg{Text: value, Attachments: attachments}))
+}
+
+// runCommand runs a command typed or picked in the editor. A custom command
+// sends its prompt with the attachments, other commands leave them for the
+// next prompt.
+func (m *editorComponent) runCommand(command commands.Command) (tea.Model, tea.Cmd) {
+	if command.IsCustom() {
+		prompt := m.app.CustomCommandPrompt(command, command.Arguments)
+		prompt.Attachments = append(m.attachments, prompt.Attachments...)
+		updated, cmd := m.Clear()
+		return updated, tea.Batch(cmd, util.CmdHandler(prompt))
+	}
+	updated, cmd := m.clearText()
+	return updated, tea.Batch(cmd, util.CmdHandler(commands.ExecuteCommandMsg(command)))
 }
 
 func (m *editorComponent) Clear() (tea.Model, tea.Cmd) {
+	m.attachments = nil
+	return m.clearText()
+}
+
+// clearText empties the editor but for its attachments.
+func (m *editorComponent) clearText() (tea.Model, tea.Cmd) {
 	m.textarea.Reset()
+	m.historyIndex = -1
+	m.draftGeneration++
+	m.SaveDraft()
 	return m, nil
 }
 
@@ -224,7 +399,7 @@ func (m *editorComponent) Paste() (tea.Model, tea.Cmd) {
 	} else {
 		m.textarea.SetValue(m.textarea.Value() + text)
 	}
-	return m, nil
+	return m, m.scheduleDraftSave()
 }
 
 func (m *editorComponent) Newline() (tea.Model, tea.Cmd) {
@@ -293,10 +468,14 @@ func NewEditorComponent(app *app.App) EditorComponent {
 	s := createSpinner()
 	ta := createTextArea(nil)
 
-	return &editorComponent{
+	editor := &editorComponent{
 		app:                    app,
 		textarea:               ta,
 		spinner:                s,
 		interruptKeyInDebounce: false,
+		historyIndex:           -1,
+		draftSession:           app.Session.ID,
 	}
+	editor.loadDraft()
+	return editor
 }
@@ -217,6 +217,21 @@ func renderContentBlock(
 	return content
 }
 
+// renderAttachments lists the files attached to a user message.
+func renderAttachments(message opencode.Message) string {
+	var names []string
+	for _, part := range message.Parts {
+		if file, ok := part.AsUnion().(opencode.FilePart); ok {
+			name := file.Filename
+			if name == "" {
+				name = file.MediaType
+			}
+			names = append(names, "["+name+"]")
+		}
+	}
+	return strings.Join(names, " ")
+}
+
 func renderText(
 	app *app.App,
 	message opencode.Message,
@@ -481,6 +496,26 @@ func renderToolDetails(
 	return renderContentBlock(app, content, highlight, width)
 }
 
+// renderShellResult shows a command run with !command that is waiting to be
+// attached to the next prompt, its output collapsed unless expanded.
+func renderShellResult(app *app.App, result app.ShellResult, expanded bool, width int) string {
+	t := theme.CurrentTheme()
+	muted := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundPanel()).Render
+	title := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundPanel()).Render("$ "+result.Command) +
+		muted("  "+result.Status()+", attached to the next prompt")
+
+	output := strings.TrimRight(result.Output, "\n")
+	if !expanded || output == "" {
+		lines := 0
+		if output != "" {
+			lines = strings.Count(output, "\n") + 1
+		}
+		return renderContentBlock(app, title+"\n"+muted(fmt.Sprintf("%d lines of output hidden", lines)), false, width)
+	}
+	body := util.ToMarkdown("```console\n"+output+"\n```", width, t.BackgroundPanel())
+	return renderContentBlock(app, title+"\n\n"+body, false, width)
+}
+
 func renderToolName(name string) string {
 	switch name {
 	case "webfetch":
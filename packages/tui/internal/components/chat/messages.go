@@ -1,6 +1,7 @@
 package chat
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/v2/viewport"
@@ -8,6 +9,7 @@ import (
 	"github.com/charmbracelet/lipgloss/v2"
 	"github.com/sst/opencode-sdk-go"
 	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/commands"
 	"github.com/sst/opencode/internal/components/dialog"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
@@ -28,6 +30,9 @@ type MessagesComponent interface {
 	Next() (tea.Model, tea.Cmd)
 	ToolDetailsVisible() bool
 	Selected() string
+	SelectedMessage() (opencode.Message, bool)
+	SelectedToolCall() string
+	Transcript() string
 }
 
 type messagesComponent struct {
@@ -42,7 +47,20 @@ type messagesComponent struct {
 	lineCount       int
 	selectedPart    int
 	selectedText    string
+	selectedMessage *opencode.Message
+	selectedTool    string
+	content         string
+	sessionID       string
+	positions       map[string]scrollPosition
+	restoreOffset   int
 }
+
+// scrollPosition is where a session was scrolled to when its tab was left.
+type scrollPosition struct {
+	offset int
+	tail   bool
+}
+
 type renderFinishedMsg struct{}
 type selectedMessagePartChangedMsg struct {
 	part int
@@ -58,6 +76,20 @@ func (m *messagesComponent) Selected() string {
 	return m.selectedText
 }
 
+// SelectedMessage returns the message the selected part belongs to.
+func (m *messagesComponent) SelectedMessage() (opencode.Message, bool) {
+	if m.selectedMessage == nil {
+		return opencode.Message{}, false
+	}
+	return *m.selectedMessage, true
+}
+
+// SelectedToolCall returns the ID of the selected tool call, if a tool call is
+// selected rather than text.
+func (m *messagesComponent) SelectedToolCall() string {
+	return m.selectedTool
+}
+
 func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
@@ -82,20 +114,44 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.Reload()
 	case app.SessionLoadedMsg:
 		m.cache.Clear()
-		m.tail = true
+		m.switchSession()
 		m.rendering = true
 		return m, m.Reload()
 	case app.SessionClearedMsg:
 		m.cache.Clear()
+		m.switchSession()
 		m.rendering = true
 		return m, m.Reload()
 	case renderFinishedMsg:
 		m.rendering = false
 		if m.tail {
 			m.viewport.GotoBottom()
+		} else if m.restoreOffset >= 0 {
+			m.viewport.SetYOffset(m.restoreOffset)
 		}
+		m.restoreOffset = -1
 	case selectedMessagePartChangedMsg:
 		return m, m.Reload()
+	case app.ShellFinishedMsg:
+		m.renderView(m.width)
+		if m.tail {
+			m.viewport.GotoBottom()
+		}
+	case commands.CommandExecutedMsg:
+		if msg.Name == commands.ShellClearCommand {
+			m.renderView(m.width)
+		}
+	case app.SessionRevertedMsg:
+		m.selectedPart = -1
+		m.renderView(m.width)
+		m.viewport.GotoBottom()
+	case app.SessionResyncedMsg:
+		if msg.Session.ID == m.app.Session.ID {
+			m.renderView(m.width)
+			if m.tail {
+				m.viewport.GotoBottom()
+			}
+		}
 	case opencode.EventListResponseEventSessionUpdated:
 		if msg.Properties.Info.ID == m.app.Session.ID {
 			m.renderView(m.width)
@@ -120,6 +176,22 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// switchSession remembers the scroll position of the session being left and
+// picks up where the newly current one was left, or its end.
+func (m *messagesComponent) switchSession() {
+	if m.sessionID != "" {
+		m.positions[m.sessionID] = scrollPosition{offset: m.viewport.YOffset, tail: m.tail}
+	}
+	m.sessionID = m.app.Session.ID
+	m.selectedPart = -1
+	m.tail = true
+	m.restoreOffset = -1
+	if position, ok := m.positions[m.sessionID]; ok {
+		m.tail = position.tail
+		m.restoreOffset = position.offset
+	}
+}
+
 func (m *messagesComponent) renderView(width int) {
 	measure := util.Measure("messages.renderView")
 	defer measure("messageCount", len(m.app.Messages))
@@ -128,8 +200,13 @@ func (m *messagesComponent) renderView(width int) {
 	blocks := make([]string, 0)
 	m.partCount = 0
 	m.lineCount = 0
+	m.selectedMessage = nil
+	m.selectedTool = ""
+	// subagent sessions are opened to see what the agent did
+	showToolDetails := m.showToolDetails || m.app.IsReadOnly()
 
-	for _, message := range m.app.Messages {
+	messages := m.app.VisibleMessages()
+	for _, message := range messages {
 		var content string
 		var cached bool
 
@@ -138,15 +215,19 @@ func (m *messagesComponent) renderView(width int) {
 			for _, part := range message.Parts {
 				switch part := part.AsUnion().(type) {
 				case opencode.TextPart:
-					key := m.cache.GenerateKey(message.ID, part.Text, width, m.selectedPart == m.partCount)
+					text := part.Text
+					if attachments := renderAttachments(message); attachments != "" {
+						text += "\n\n" + attachments
+					}
+					key := m.cache.GenerateKey(message.ID, text, width, m.selectedPart == m.partCount)
 					content, cached = m.cache.Get(key)
 					if !cached {
 						content = renderText(
 							m.app,
 							message,
-							part.Text,
+							text,
 							m.app.Info.User,
-							m.showToolDetails,
+							showToolDetails,
 							m.partCount == m.selectedPart,
 							width,
 						)
@@ -156,6 +237,7 @@ func (m *messagesComponent) renderView(width int) {
 						if m.selectedPart == m.partCount {
 							m.viewport.SetYOffset(m.lineCount - 4)
 							m.selectedText = part.Text
+							m.selectedMessage = &message
 						}
 						blocks = append(blocks, content)
 						m.partCount++
@@ -188,7 +270,7 @@ func (m *messagesComponent) renderView(width int) {
 					}
 
 					if finished {
-						key := m.cache.GenerateKey(message.ID, p.Text, width, m.showToolDetails, m.selectedPart == m.partCount)
+						key := m.cache.GenerateKey(message.ID, p.Text, width, showToolDetails, m.selectedPart == m.partCount)
 						content, cached = m.cache.Get(key)
 						if !cached {
 							content = renderText(
@@ -196,7 +278,7 @@ func (m *messagesComponent) renderView(width int) {
 								message,
 								p.Text,
 								message.Metadata.Assistant.ModelID,
-								m.showToolDetails,
+								showToolDetails,
 								m.partCount == m.selectedPart,
 								width,
 								toolCallParts...,
@@ -209,7 +291,7 @@ func (m *messagesComponent) renderView(width int) {
 							message,
 							p.Text,
 							message.Metadata.Assistant.ModelID,
-							m.showToolDetails,
+							showToolDetails,
 							m.partCount == m.selectedPart,
 							width,
 							toolCallParts...,
@@ -219,20 +301,21 @@ func (m *messagesComponent) renderView(width int) {
 						if m.selectedPart == m.partCount {
 							m.viewport.SetYOffset(m.lineCount - 4)
 							m.selectedText = p.Text
+							m.selectedMessage = &message
 						}
 						blocks = append(blocks, content)
 						m.partCount++
 						m.lineCount += lipgloss.Height(content) + 1
 					}
 				case opencode.ToolInvocationPart:
-					if !m.showToolDetails {
+					if !showToolDetails {
 						continue
 					}
 
 					if part.ToolInvocation.State == "result" {
 						key := m.cache.GenerateKey(message.ID,
 							part.ToolInvocation.ToolCallID,
-							m.showToolDetails,
+							showToolDetails,
 							width,
 							m.partCount == m.selectedPart,
 						)
@@ -261,6 +344,8 @@ func (m *messagesComponent) renderView(width int) {
 						if m.selectedPart == m.partCount {
 							m.viewport.SetYOffset(m.lineCount - 4)
 							m.selectedText = ""
+							m.selectedMessage = &message
+							m.selectedTool = part.ToolInvocation.ToolCallID
 						}
 						blocks = append(blocks, content)
 						m.partCount++
@@ -271,16 +356,31 @@ func (m *messagesComponent) renderView(width int) {
 		}
 
 		error := ""
+		hints := []commands.CommandName{commands.SessionRetryCommand, commands.SessionRetryModelCommand}
 		switch err := message.Metadata.Error.AsUnion().(type) {
 		case nil:
 		case opencode.MessageMetadataErrorMessageOutputLengthError:
 			error = "Message output length exceeded"
+			hints = append([]commands.CommandName{commands.SessionContinueCommand}, hints...)
 		case opencode.ProviderAuthError:
 			error = err.Data.Message
 		case opencode.UnknownError:
 			error = err.Data.Message
 		}
 
+		// only the last reply can be retried
+		if error != "" && message.ID == messages[len(messages)-1].ID {
+			var actions []string
+			for _, name := range hints {
+				if key := m.app.KeyBinding(name); key != "" {
+					actions = append(actions, key+" "+m.app.Commands[name].Description)
+				}
+			}
+			if len(actions) > 0 {
+				error += "\n\n" + strings.Join(actions, ", ")
+			}
+		}
+
 		if error != "" {
 			error = renderContentBlock(
 				m.app,
@@ -294,12 +394,42 @@ func (m *messagesComponent) renderView(width int) {
 		}
 	}
 
-	m.viewport.SetContent("\n" + strings.Join(blocks, "\n\n"))
+	if reverted := len(m.app.Messages) - len(messages); reverted > 0 {
+		notice := fmt.Sprintf("%d reverted message(s) hidden", reverted)
+		if key := m.app.KeyBinding(commands.MessagesRedoCommand); key != "" {
+			notice += ", " + key + " to restore them"
+		}
+		notice = renderContentBlock(
+			m.app,
+			notice,
+			false,
+			width,
+			WithBorderColor(t.Warning()),
+		)
+		blocks = append(blocks, notice)
+		m.lineCount += lipgloss.Height(notice) + 1
+	}
+
+	for _, result := range m.app.ShellResults() {
+		block := renderShellResult(m.app, result, m.showToolDetails, width)
+		blocks = append(blocks, block)
+		m.lineCount += lipgloss.Height(block) + 1
+	}
+
+	m.content = "\n" + strings.Join(blocks, "\n\n")
+	m.viewport.SetContent(m.content)
 	if m.selectedPart == m.partCount-1 {
 		m.viewport.GotoBottom()
 	}
 }
 
+// Transcript returns the whole session as rendered, not only the part that
+// fits the viewport.
+func (m *messagesComponent) Transcript() string {
+	m.renderView(m.width)
+	return m.header(m.width) + m.content
+}
+
 func (m *messagesComponent) header(width int) string {
 	if m.app.Session.ID == "" {
 		return ""
@@ -310,8 +440,11 @@ func (m *messagesComponent) header(width int) string {
 	muted := styles.NewStyle().Foreground(t.TextMuted()).Background(t.Background()).Render
 	headerLines := []string{}
 	headerLines = append(headerLines, util.ToMarkdown("# "+m.app.Session.Title, width-6, t.Background()))
-	if m.app.Session.Share.URL != "" {
-		headerLines = append(headerLines, muted(m.app.Session.Share.URL))
+	if m.app.IsReadOnly() {
+		headerLines = append(headerLines, muted("Subagent session, read only"))
+	} else if m.app.Session.Share.URL != "" {
+		shared := styles.NewStyle().Foreground(t.Success()).Background(t.Background()).Render("● ")
+		headerLines = append(headerLines, shared+base("Shared ")+muted(m.app.Session.Share.URL))
 	} else {
 		headerLines = append(headerLines, base("/share")+muted(" to create a shareable link"))
 	}
@@ -450,5 +583,7 @@ func NewMessagesComponent(app *app.App) MessagesComponent {
 		cache:           NewMessageCache(),
 		tail:            true,
 		selectedPart:    -1,
+		positions:       make(map[string]scrollPosition),
+		restoreOffset:   -1,
 	}
 }
@@ -0,0 +1,50 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/muesli/reflow/truncate"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/commands"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+)
+
+const maxQueuedPromptsShown = 3
+
+// QueueView renders the prompts waiting to be sent in the current session,
+// to be shown above the editor. It is empty when nothing is queued.
+func QueueView(app *app.App, width int) string {
+	prompts := app.QueuedPrompts()
+	if len(prompts) == 0 {
+		return ""
+	}
+	t := theme.CurrentTheme()
+	base := styles.NewStyle().Foreground(t.Text()).Background(t.Background()).Render
+	muted := styles.NewStyle().Foreground(t.TextMuted()).Background(t.Background()).Render
+
+	lines := []string{}
+	for i, prompt := range prompts {
+		if i == maxQueuedPromptsShown {
+			lines = append(lines, muted(fmt.Sprintf("  …and %d more", len(prompts)-i)))
+			break
+		}
+		text, _, _ := strings.Cut(prompt.Text, "\n")
+		prefix := fmt.Sprintf("%d. ", i+1)
+		text = truncate.StringWithTail(text, uint(max(0, width-4-len(prefix))), "…")
+		lines = append(lines, muted("  "+prefix)+base(text))
+	}
+
+	hint := muted(fmt.Sprintf("%d queued", len(prompts)))
+	if key := app.KeyBinding(commands.SessionQueueCommand); key != "" {
+		hint += muted("  ") + base(key) + muted(" edit queue")
+	}
+	lines = append([]string{hint}, lines...)
+
+	return styles.NewStyle().
+		Background(t.Background()).
+		Width(width).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
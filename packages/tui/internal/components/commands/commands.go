@@ -53,11 +53,14 @@ func (c *commandsComponent) View() string {
 
 	var commandsToShow []commands.Command
 	var triggeredCommands []commands.Command
+	var customCommands []commands.Command
 	var untriggeredCommands []commands.Command
 
 	for _, cmd := range c.app.Commands.Sorted() {
 		if c.showAll || cmd.Trigger != "" {
-			if cmd.Trigger != "" {
+			if cmd.IsCustom() {
+				customCommands = append(customCommands, cmd)
+			} else if cmd.Trigger != "" {
 				triggeredCommands = append(triggeredCommands, cmd)
 			} else if c.showAll {
 				untriggeredCommands = append(untriggeredCommands, cmd)
@@ -65,8 +68,10 @@ func (c *commandsComponent) View() string {
 		}
 	}
 
-	// Combine triggered commands first, then untriggered
+	// Combine triggered commands first, then custom ones so that they don't
+	// push the built-in ones past the limit, then untriggered
 	commandsToShow = append(commandsToShow, triggeredCommands...)
+	commandsToShow = append(commandsToShow, customCommands...)
 	commandsToShow = append(commandsToShow, untriggeredCommands...)
 
 	if c.limit != nil && len(commandsToShow) > *c.limit {
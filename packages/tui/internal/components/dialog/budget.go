@@ -0,0 +1,98 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// BudgetConfirmedMsg is sent when the user chooses to send a prompt held back
+// by a budget, either going past the limit or raising it.
+type BudgetConfirmedMsg struct {
+	Exceeded app.BudgetExceededMsg
+	Raise    bool
+}
+
+// BudgetDeclinedMsg gives back the prompt held back by a budget when the user
+// chooses not to send it.
+type BudgetDeclinedMsg struct {
+	Text        string
+	Attachments []app.Attachment
+}
+
+// BudgetDialog interface for the budget confirmation dialog
+type BudgetDialog interface {
+	layout.Modal
+}
+
+type budgetDialog struct {
+	modal     *modal.Modal
+	exceeded  app.BudgetExceededMsg
+	confirmed bool
+}
+
+func (b *budgetDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (b *budgetDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "enter", "y", "r":
+			b.confirmed = true
+			return b, tea.Sequence(
+				util.CmdHandler(modal.CloseModalMsg{}),
+				util.CmdHandler(BudgetConfirmedMsg{Exceeded: b.exceeded, Raise: msg.String() == "r"}),
+			)
+		case "n":
+			return b, util.CmdHandler(modal.CloseModalMsg{})
+		}
+	}
+	return b, nil
+}
+
+func (b *budgetDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	textStyle := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement())
+	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundElement())
+
+	lines := []string{
+		textStyle.Render(fmt.Sprintf("%s spend is $%.2f, the limit is $%.2f.", b.exceeded.Budget, b.exceeded.Spent, b.exceeded.Limit)),
+		"",
+		mutedStyle.Render("Send the prompt anyway?"),
+	}
+
+	helpText := textStyle.Render("enter") + mutedStyle.Render(" send  ") +
+		textStyle.Render("r") + mutedStyle.Render(" raise limit  ") +
+		textStyle.Render("esc") + mutedStyle.Render(" cancel")
+	lines = append(lines, "", helpText)
+
+	content := styles.NewStyle().PaddingLeft(1).Render(strings.Join(lines, "\n"))
+	return b.modal.Render(content, background)
+}
+
+func (b *budgetDialog) Close() tea.Cmd {
+	if b.confirmed {
+		return nil
+	}
+	return util.CmdHandler(BudgetDeclinedMsg{Text: b.exceeded.Text, Attachments: b.exceeded.Attachments})
+}
+
+// NewBudgetDialog asks whether to send a prompt past the limit of a budget.
+func NewBudgetDialog(exceeded app.BudgetExceededMsg) BudgetDialog {
+	return &budgetDialog{
+		exceeded: exceeded,
+		modal: modal.New(
+			modal.WithTitle("Budget reached"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+}
@@ -2,6 +2,7 @@ package dialog
 
 import (
 	"log/slog"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/v2/key"
 	"github.com/charmbracelet/bubbles/v2/textarea"
@@ -62,6 +63,12 @@ type CompletionProvider interface {
 	GetEmptyMessage() string
 }
 
+// ArgumentProvider is implemented by providers whose entries take arguments,
+// which are completed once a space follows the entry.
+type ArgumentProvider interface {
+	TakesArguments(entry string) bool
+}
+
 type CompletionSelectedMsg struct {
 	SearchString    string
 	CompletionValue string
@@ -154,6 +161,10 @@ func (c *completionDialogComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return c, c.complete(item)
 			case key.Matches(msg, completionDialogKeys.Cancel):
+				// Spaces separate arguments from entries that take them
+				if msg.String() == " " && c.takesArguments() {
+					break
+				}
 				// Only close on backspace when there are no characters left
 				if msg.String() != "backspace" || len(c.pseudoSearchTextArea.Value()) <= 0 {
 					return c, c.close()
@@ -178,6 +189,15 @@ func (c *completionDialogComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return c, tea.Batch(cmds...)
 }
 
+func (c *completionDialogComponent) takesArguments() bool {
+	provider, ok := c.completionProvider.(ArgumentProvider)
+	if !ok {
+		return false
+	}
+	entry, _, _ := strings.Cut(c.query, " ")
+	return provider.TakesArguments(entry)
+}
+
 func (c *completionDialogComponent) View() string {
 	t := theme.CurrentTheme()
 	baseStyle := styles.NewStyle().Foreground(t.Text())
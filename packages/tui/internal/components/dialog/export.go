@@ -0,0 +1,122 @@
+package dialog
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// ExportConfirmedMsg is sent when a path has been chosen in the export
+// dialog.
+type ExportConfirmedMsg struct {
+	Path   string
+	Format app.ExportFormat
+}
+
+// ExportDialog interface for the session export dialog
+type ExportDialog interface {
+	layout.Modal
+}
+
+type exportDialog struct {
+	modal     *modal.Modal
+	textInput textinput.Model
+	format    app.ExportFormat
+}
+
+func (e *exportDialog) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (e *exportDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "enter":
+			path := strings.TrimSpace(e.textInput.Value())
+			if path == "" {
+				return e, nil
+			}
+			return e, tea.Sequence(
+				util.CmdHandler(modal.CloseModalMsg{}),
+				util.CmdHandler(ExportConfirmedMsg{Path: path, Format: e.format}),
+			)
+		case "tab":
+			index := slices.Index(app.ExportFormats, e.format)
+			next := app.ExportFormats[(index+1)%len(app.ExportFormats)]
+			// follow the format with the extension unless it was changed
+			path := e.textInput.Value()
+			if strings.HasSuffix(path, e.format.Extension()) {
+				e.textInput.SetValue(strings.TrimSuffix(path, e.format.Extension()) + next.Extension())
+			}
+			e.format = next
+			return e, nil
+		}
+	}
+	var cmd tea.Cmd
+	e.textInput, cmd = e.textInput.Update(msg)
+	return e, cmd
+}
+
+func (e *exportDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width - 12
+	textStyle := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement())
+	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundElement())
+
+	e.textInput.SetWidth(width - 2)
+	inputView := styles.NewStyle().
+		Background(t.BackgroundPanel()).
+		Width(width).
+		Render(e.textInput.View())
+
+	var formats []string
+	for _, format := range app.ExportFormats {
+		if format == e.format {
+			formats = append(formats, textStyle.Bold(true).Render(string(format)))
+		} else {
+			formats = append(formats, mutedStyle.Render(string(format)))
+		}
+	}
+
+	helpText := textStyle.Render("enter") + mutedStyle.Render(" export  ") +
+		textStyle.Render("tab") + mutedStyle.Render(" format  ") +
+		textStyle.Render("esc") + mutedStyle.Render(" cancel")
+
+	content := strings.Join([]string{
+		inputView,
+		"",
+		styles.NewStyle().PaddingLeft(1).Render(strings.Join(formats, mutedStyle.Render(" · "))),
+		"",
+		styles.NewStyle().PaddingLeft(1).Render(helpText),
+	}, "\n")
+	return e.modal.Render(content, background)
+}
+
+func (e *exportDialog) Close() tea.Cmd {
+	e.textInput.Blur()
+	return nil
+}
+
+// NewExportDialog asks where to export the current session to, starting
+// from the given path to a Markdown file.
+func NewExportDialog(path string) ExportDialog {
+	textInput := createTextInput(nil)
+	textInput.SetValue(path)
+	return &exportDialog{
+		textInput: textInput,
+		format:    app.ExportMarkdown,
+		modal: modal.New(
+			modal.WithTitle("Export Session"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+}
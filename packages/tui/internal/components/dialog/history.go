@@ -0,0 +1,150 @@
+package dialog
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// HistorySelectedMsg is sent when a prompt has been picked from the history.
+type HistorySelectedMsg struct {
+	Text string
+}
+
+// HistoryDialog interface for the prompt history search dialog
+type HistoryDialog interface {
+	layout.Modal
+}
+
+type historyDialog struct {
+	modal   *modal.Modal
+	query   textinput.Model
+	list    list.List[list.StringItem]
+	history []string
+	matches []string // newest first
+}
+
+func (h *historyDialog) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (h *historyDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h.list.SetMaxWidth(layout.Current.Container.Width - 12)
+	case tea.PasteMsg:
+		var cmd tea.Cmd
+		h.query, cmd = h.query.Update(msg)
+		h.refresh()
+		return h, cmd
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "enter":
+			if _, idx := h.list.GetSelectedItem(); idx >= 0 && idx < len(h.matches) {
+				return h, tea.Sequence(
+					util.CmdHandler(modal.CloseModalMsg{}),
+					util.CmdHandler(HistorySelectedMsg{Text: h.matches[idx]}),
+				)
+			}
+			return h, nil
+		case "ctrl+r":
+			// like a shell's reverse search, go on to the next older match
+			if _, idx := h.list.GetSelectedItem(); idx+1 < len(h.matches) {
+				h.list.SetSelectedIndex(idx + 1)
+			}
+			return h, nil
+		case "up", "down":
+			listModel, cmd := h.list.Update(msg)
+			h.list = listModel.(list.List[list.StringItem])
+			return h, cmd
+		}
+
+		var cmd tea.Cmd
+		query := h.query.Value()
+		h.query, cmd = h.query.Update(msg)
+		if h.query.Value() != query {
+			h.refresh()
+		}
+		return h, cmd
+	}
+	return h, nil
+}
+
+// refresh lists the prompts matching the query, newest first.
+func (h *historyDialog) refresh() {
+	query := h.query.Value()
+	h.matches = h.matches[:0]
+	var items []list.StringItem
+	for i := len(h.history) - 1; i >= 0; i-- {
+		prompt := h.history[i]
+		if query != "" && !fuzzy.MatchFold(query, prompt) {
+			continue
+		}
+		h.matches = append(h.matches, prompt)
+		items = append(items, list.StringItem(strings.Join(strings.Fields(prompt), " ")))
+	}
+	h.list.SetItems(items)
+	h.list.SetSelectedIndex(0)
+}
+
+func (h *historyDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width - 12
+	base := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement()).Render
+	muted := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundElement()).Render
+
+	h.query.SetWidth(width - 2)
+	inputView := styles.NewStyle().
+		Background(t.BackgroundPanel()).
+		Width(width).
+		Render(h.query.View())
+
+	helpText := base("enter") + muted(" use  ") +
+		base("ctrl+r") + muted(" older match")
+	helpText = styles.NewStyle().PaddingLeft(1).PaddingTop(1).Render(helpText)
+
+	content := strings.Join([]string{inputView, h.list.View(), helpText}, "\n")
+	return h.modal.Render(content, background)
+}
+
+func (h *historyDialog) Close() tea.Cmd {
+	h.query.Blur()
+	return nil
+}
+
+// NewHistoryDialog searches the prompts sent in the project, starting from
+// the given query.
+func NewHistoryDialog(app *app.App, query string) HistoryDialog {
+	input := createTextInput(nil)
+	input.Placeholder = "Search history"
+	input.SetValue(query)
+
+	listComponent := list.NewStringList(
+		[]string{},
+		10, // maxVisibleItems
+		"No matching prompts",
+		false, // letters go to the search
+	)
+	listComponent.SetMaxWidth(layout.Current.Container.Width - 12)
+
+	dialog := &historyDialog{
+		query:   input,
+		list:    listComponent,
+		history: app.History(),
+		modal: modal.New(
+			modal.WithTitle("Prompt History"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+	dialog.refresh()
+	return dialog
+}
@@ -0,0 +1,97 @@
+package dialog
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// ImportConfirmedMsg is sent when a file has been chosen in the import
+// dialog.
+type ImportConfirmedMsg struct {
+	Path string
+}
+
+// ImportDialog interface for the session import dialog
+type ImportDialog interface {
+	layout.Modal
+}
+
+type importDialog struct {
+	modal     *modal.Modal
+	textInput textinput.Model
+}
+
+func (i *importDialog) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (i *importDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "enter":
+			path := strings.TrimSpace(i.textInput.Value())
+			if path == "" {
+				return i, nil
+			}
+			return i, tea.Sequence(
+				util.CmdHandler(modal.CloseModalMsg{}),
+				util.CmdHandler(ImportConfirmedMsg{Path: path}),
+			)
+		}
+	}
+	var cmd tea.Cmd
+	i.textInput, cmd = i.textInput.Update(msg)
+	return i, cmd
+}
+
+func (i *importDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width - 12
+	textStyle := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement())
+	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundElement())
+
+	i.textInput.SetWidth(width - 2)
+	inputView := styles.NewStyle().
+		Background(t.BackgroundPanel()).
+		Width(width).
+		Render(i.textInput.View())
+
+	helpText := textStyle.Render("enter") + mutedStyle.Render(" import  ") +
+		textStyle.Render("esc") + mutedStyle.Render(" cancel")
+
+	content := strings.Join([]string{
+		inputView,
+		"",
+		styles.NewStyle().PaddingLeft(1).Render(mutedStyle.Render("a .json or .md session export")),
+		"",
+		styles.NewStyle().PaddingLeft(1).Render(helpText),
+	}, "\n")
+	return i.modal.Render(content, background)
+}
+
+func (i *importDialog) Close() tea.Cmd {
+	i.textInput.Blur()
+	return nil
+}
+
+// NewImportDialog asks for a session export to import, starting from the
+// given directory.
+func NewImportDialog(dir string) ImportDialog {
+	textInput := createTextInput(nil)
+	textInput.SetValue(strings.TrimSuffix(dir, "/") + "/")
+	return &importDialog{
+		textInput: textInput,
+		modal: modal.New(
+			modal.WithTitle("Import Session"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+}
@@ -37,6 +37,18 @@ type modelDialog struct {
 	modal       *modal.Modal
 	modelList   list.List[ModelItem]
 	dialogWidth int
+	retry       bool
+}
+
+// ModelDialogOption configures the model dialog.
+type ModelDialogOption func(*modelDialog)
+
+// WithRetry makes the model dialog retry the last prompt with the chosen
+// model.
+func WithRetry() ModelDialogOption {
+	return func(m *modelDialog) {
+		m.retry = true
+	}
 }
 
 type ModelWithProvider struct {
@@ -108,14 +120,18 @@ func (m *modelDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			_, selectedIndex := m.modelList.GetSelectedItem()
 			if selectedIndex >= 0 && selectedIndex < len(m.allModels) {
 				selectedModel := m.allModels[selectedIndex]
-				return m, tea.Sequence(
+				cmds := []tea.Cmd{
 					util.CmdHandler(modal.CloseModalMsg{}),
 					util.CmdHandler(
 						app.ModelSelectedMsg{
 							Provider: selectedModel.Provider,
 							Model:    selectedModel.Model,
 						}),
-				)
+				}
+				if m.retry {
+					cmds = append(cmds, util.CmdHandler(app.RetryMsg{}))
+				}
+				return m, tea.Sequence(cmds...)
 			}
 			return m, util.CmdHandler(modal.CloseModalMsg{})
 		case key.Matches(msg, modelKeys.Escape):
@@ -256,15 +272,22 @@ func (s *modelDialog) Close() tea.Cmd {
 	return nil
 }
 
-func NewModelDialog(app *app.App) ModelDialog {
+func NewModelDialog(app *app.App, opts ...ModelDialogOption) ModelDialog {
 	dialog := &modelDialog{
 		app: app,
 	}
+	for _, opt := range opts {
+		opt(dialog)
+	}
 
 	dialog.setupAllModels()
 
+	title := "Select Model"
+	if dialog.retry {
+		title = "Retry With Model"
+	}
 	dialog.modal = modal.New(
-		modal.WithTitle("Select Model"),
+		modal.WithTitle(title),
 		modal.WithMaxWidth(dialog.dialogWidth+4),
 	)
 
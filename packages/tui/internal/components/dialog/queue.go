@@ -0,0 +1,129 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// QueuedPromptEditMsg is sent when the queued prompt at Index is chosen to be
+// edited.
+type QueuedPromptEditMsg struct {
+	Index int
+}
+
+// QueueDialog interface for the queued prompts dialog
+type QueueDialog interface {
+	layout.Modal
+}
+
+type queueDialog struct {
+	app   *app.App
+	modal *modal.Modal
+	list  list.List[list.StringItem]
+}
+
+func (q *queueDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (q *queueDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		q.list.SetMaxWidth(layout.Current.Container.Width - 12)
+	case tea.KeyPressMsg:
+		_, idx := q.list.GetSelectedItem()
+		switch msg.String() {
+		case "enter", "e":
+			if idx >= 0 {
+				return q, tea.Sequence(
+					util.CmdHandler(modal.CloseModalMsg{}),
+					util.CmdHandler(QueuedPromptEditMsg{Index: idx}),
+				)
+			}
+		case "x", "delete", "backspace":
+			if _, ok := q.app.RemoveQueuedPrompt(idx); ok {
+				q.updateListItems(min(idx, len(q.app.QueuedPrompts())-1))
+			}
+			return q, nil
+		case "shift+up", "K":
+			q.updateListItems(q.app.MoveQueuedPrompt(idx, -1))
+			return q, nil
+		case "shift+down", "J":
+			q.updateListItems(q.app.MoveQueuedPrompt(idx, 1))
+			return q, nil
+		}
+	}
+
+	listModel, cmd := q.list.Update(msg)
+	q.list = listModel.(list.List[list.StringItem])
+	return q, cmd
+}
+
+func (q *queueDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	base := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement()).Render
+	muted := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundElement()).Render
+
+	helpText := base("enter") + muted(" edit  ") +
+		base("shift+↑↓") + muted(" move  ") +
+		base("x/del") + muted(" cancel")
+	helpText = styles.NewStyle().PaddingLeft(1).PaddingTop(1).Render(helpText)
+
+	content := strings.Join([]string{q.list.View(), helpText}, "\n")
+	return q.modal.Render(content, background)
+}
+
+func (q *queueDialog) updateListItems(selected int) {
+	var items []list.StringItem
+	for _, prompt := range q.app.QueuedPrompts() {
+		items = append(items, list.StringItem(queuedPromptTitle(prompt)))
+	}
+	q.list.SetItems(items)
+	q.list.SetSelectedIndex(selected)
+}
+
+func (q *queueDialog) Close() tea.Cmd {
+	return nil
+}
+
+// queuedPromptTitle is the first line of a queued prompt, with a count of
+// its attachments.
+func queuedPromptTitle(prompt app.QueuedPrompt) string {
+	title, _, _ := strings.Cut(prompt.Text, "\n")
+	if len(prompt.Attachments) > 0 {
+		title += fmt.Sprintf(" (+%d attachments)", len(prompt.Attachments))
+	}
+	return title
+}
+
+// NewQueueDialog lists the prompts queued in the current session, which can
+// be edited, reordered or cancelled before they are sent.
+func NewQueueDialog(app *app.App) QueueDialog {
+	listComponent := list.NewStringList(
+		[]string{},
+		10, // maxVisibleItems
+		"No queued prompts",
+		true, // useAlphaNumericKeys
+	)
+	listComponent.SetMaxWidth(layout.Current.Container.Width - 12)
+
+	dialog := &queueDialog{
+		app:  app,
+		list: listComponent,
+		modal: modal.New(
+			modal.WithTitle("Queued Prompts"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+	dialog.updateListItems(0)
+	return dialog
+}
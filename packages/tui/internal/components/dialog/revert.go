@@ -0,0 +1,100 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+const maxRevertFilesShown = 10
+
+// RevertConfirmedMsg is sent when a revert is confirmed in the revert dialog.
+type RevertConfirmedMsg struct {
+	MessageID string
+}
+
+// RevertDialog interface for the revert confirmation dialog
+type RevertDialog interface {
+	layout.Modal
+}
+
+type revertDialog struct {
+	modal     *modal.Modal
+	messageID string
+	messages  int
+	files     []string
+}
+
+func (r *revertDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (r *revertDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "enter", "y":
+			return r, tea.Sequence(
+				util.CmdHandler(modal.CloseModalMsg{}),
+				util.CmdHandler(RevertConfirmedMsg{MessageID: r.messageID}),
+			)
+		case "n":
+			return r, util.CmdHandler(modal.CloseModalMsg{})
+		}
+	}
+	return r, nil
+}
+
+func (r *revertDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	textStyle := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement())
+	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundElement())
+
+	lines := []string{
+		textStyle.Render(fmt.Sprintf("%d message(s) will be reverted.", r.messages)),
+		"",
+	}
+	if len(r.files) == 0 {
+		lines = append(lines, mutedStyle.Render("No files were changed since this message."))
+	} else {
+		lines = append(lines, textStyle.Render("These files will be restored:"))
+		for i, file := range r.files {
+			if i == maxRevertFilesShown {
+				lines = append(lines, mutedStyle.Render(fmt.Sprintf("  …and %d more", len(r.files)-i)))
+				break
+			}
+			lines = append(lines, mutedStyle.Render("  "+file))
+		}
+	}
+
+	helpText := textStyle.Render("enter") + mutedStyle.Render(" revert  ") +
+		textStyle.Render("esc") + mutedStyle.Render(" cancel")
+	lines = append(lines, "", helpText)
+
+	content := styles.NewStyle().PaddingLeft(1).Render(strings.Join(lines, "\n"))
+	return r.modal.Render(content, background)
+}
+
+func (r *revertDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewRevertDialog asks to confirm reverting the session to before a message,
+// listing the files that will be restored.
+func NewRevertDialog(messageID string, messages int, files []string) RevertDialog {
+	return &revertDialog{
+		messageID: messageID,
+		messages:  messages,
+		files:     files,
+		modal: modal.New(
+			modal.WithTitle("Revert"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+}
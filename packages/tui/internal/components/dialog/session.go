@@ -2,11 +2,17 @@ package dialog
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"slices"
 
+	"github.com/charmbracelet/bubbles/v2/textinput"
 	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/lithammer/fuzzysearch/fuzzy"
 	"github.com/muesli/reflow/truncate"
 	"github.com/sst/opencode-sdk-go"
 	"github.com/sst/opencode/internal/app"
@@ -24,22 +30,62 @@ type SessionDialog interface {
 	layout.Modal
 }
 
+// sessionSort is the order sessions are listed in while not searching.
+type sessionSort int
+
+const (
+	sortRecent sessionSort = iota
+	sortCost
+	sortName
+)
+
+func (s sessionSort) String() string {
+	switch s {
+	case sortCost:
+		return "cost"
+	case sortName:
+		return "name"
+	default:
+		return "recent"
+	}
+}
+
+// sessionStatsMsg carries the stats of sessions loaded in the background.
+type sessionStatsMsg map[string]app.SessionStats
+
+type sessionRenamedMsg struct {
+	session opencode.Session
+}
+
 // sessionItem is a custom list item for sessions that can show delete confirmation
 type sessionItem struct {
 	title              string
+	depth              int
 	isDeleteConfirming bool
+	// rename is the rendered rename input, shown instead of the title
+	rename string
 }
 
 func (s sessionItem) Render(selected bool, width int) string {
 	t := theme.CurrentTheme()
 	baseStyle := styles.NewStyle()
 
+	if s.rename != "" {
+		return baseStyle.
+			Background(t.BackgroundElement()).
+			Width(width).
+			Render(s.rename)
+	}
+
 	var text string
 	if s.isDeleteConfirming {
 		text = "Press again to confirm delete"
 	} else {
 		text = s.title
 	}
+	if s.depth > 0 {
+		text = strings.Repeat("  ", s.depth-1) + "└ " + text
+	}
 
 	truncatedStr := truncate.StringWithTail(text, uint(width-1), "...")
 
@@ -79,14 +125,22 @@ type sessionDialog struct {
 	width              int
 	height             int
 	modal              *modal.Modal
-	sessions           []opencode.Session
+	all                []opencode.Session
+	sessions           []opencode.Session // shown, in list order
+	depths             []int
+	stats              map[string]app.SessionStats
+	loading            map[string]bool
+	sort               sessionSort
+	query              textinput.Model
+	renameInput        textinput.Model
+	renaming           int // -1 means not renaming, >= 0 is the index of the session being renamed
 	list               list.List[sessionItem]
 	app                *app.App
 	deleteConfirmation int // -1 means no confirmation, >= 0 means confirming deletion of session at this index
 }
 
 func (s *sessionDialog) Init() tea.Cmd {
-	return nil
+	return tea.Batch(textinput.Blink, s.loadSelectedStats())
 }
 
 func (s *sessionDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -95,7 +149,38 @@ func (s *sessionDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		s.width = msg.Width
 		s.height = msg.Height
 		s.list.SetMaxWidth(layout.Current.Container.Width - 12)
+	case sessionStatsMsg:
+		for id, stats := range msg {
+			s.stats[id] = stats
+			delete(s.loading, id)
+		}
+		if s.sort == sortCost {
+			s.refresh()
+		}
+		return s, nil
+	case sessionRenamedMsg:
+		index := slices.IndexFunc(s.all, func(sess opencode.Session) bool { return sess.ID == msg.session.ID })
+		if index >= 0 {
+			s.all[index] = msg.session
+		}
+		s.refresh()
+		return s, nil
+	case tea.PasteMsg:
+		if s.renaming >= 0 {
+			var cmd tea.Cmd
+			s.renameInput, cmd = s.renameInput.Update(msg)
+			s.updateListItems()
+			return s, cmd
+		}
+		var cmd tea.Cmd
+		s.query, cmd = s.query.Update(msg)
+		s.refresh()
+		s.list.SetSelectedIndex(0)
+		return s, tea.Batch(cmd, s.loadSelectedStats())
 	case tea.KeyPressMsg:
+		if s.renaming >= 0 {
+			return s.updateRename(msg)
+		}
 		switch msg.String() {
 		case "enter":
 			if s.deleteConfirmation >= 0 {
@@ -107,19 +192,22 @@ func (s *sessionDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				selectedSession := s.sessions[idx]
 				return s, tea.Sequence(
 					util.CmdHandler(modal.CloseModalMsg{}),
-					util.CmdHandler(app.SessionSelectedMsg(&selectedSession)),
+					s.app.SelectSession(context.Background(), selectedSession),
 				)
 			}
-		case "x", "delete", "backspace":
+			return s, nil
+		case "ctrl+d":
 			if _, idx := s.list.GetSelectedItem(); idx >= 0 && idx < len(s.sessions) {
 				if s.deleteConfirmation == idx {
 					// Second press - actually delete the session
 					sessionToDelete := s.sessions[idx]
 					return s, tea.Sequence(
 						func() tea.Msg {
-							s.sessions = slices.Delete(s.sessions, idx, idx+1)
+							s.all = slices.DeleteFunc(s.all, func(sess opencode.Session) bool {
+								return sess.ID == sessionToDelete.ID
+							})
 							s.deleteConfirmation = -1
-							s.updateListItems()
+							s.refresh()
 							return nil
 						},
 						s.deleteSession(sessionToDelete.ID),
@@ -131,13 +219,58 @@ func (s *sessionDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return s, nil
 				}
 			}
+			return s, nil
+		case "ctrl+r":
+			if _, idx := s.list.GetSelectedItem(); idx >= 0 && idx < len(s.sessions) {
+				s.deleteConfirmation = -1
+				s.renaming = idx
+				s.renameInput.SetValue(s.sessions[idx].Title)
+				s.renameInput.CursorEnd()
+				s.query.Blur()
+				s.updateListItems()
+				return s, s.renameInput.Focus()
+			}
+			return s, nil
+		case "tab":
+			s.sort = (s.sort + 1) % 3
+			s.refresh()
+			if s.sort == sortCost {
+				return s, s.loadStats(s.all)
+			}
+			return s, nil
+		case "ctrl+c":
+			// clear the search first, close the dialog once there is none
+			if s.query.Value() == "" {
+				return s, util.CmdHandler(modal.CloseModalMsg{})
+			}
+			s.query.SetValue("")
+			s.refresh()
+			return s, textinput.Blink
 		case "esc":
 			if s.deleteConfirmation >= 0 {
 				s.deleteConfirmation = -1
 				s.updateListItems()
 				return s, nil
 			}
+		case "up", "down":
+			if s.deleteConfirmation >= 0 {
+				s.deleteConfirmation = -1
+				s.updateListItems()
+			}
+			listModel, cmd := s.list.Update(msg)
+			s.list = listModel.(list.List[sessionItem])
+			return s, tea.Batch(cmd, s.loadSelectedStats())
 		}
+
+		s.deleteConfirmation = -1
+		var cmd tea.Cmd
+		query := s.query.Value()
+		s.query, cmd = s.query.Update(msg)
+		if s.query.Value() != query {
+			s.refresh()
+			s.list.SetSelectedIndex(0)
+		}
+		return s, tea.Batch(cmd, s.loadSelectedStats())
 	}
 
 	var cmd tea.Cmd
@@ -146,20 +279,150 @@ func (s *sessionDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return s, cmd
 }
 
+// Confirming reports whether a session is waiting for its deletion to be
+// confirmed.
+func (s *sessionDialog) Confirming() bool {
+	return s.deleteConfirmation >= 0
+}
+
+// updateRename handles keys while a session title is being edited inline.
+func (s *sessionDialog) updateRename(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		session := s.sessions[s.renaming]
+		title := strings.TrimSpace(s.renameInput.Value())
+		s.stopRename()
+		if title == "" || title == session.Title {
+			return s, nil
+		}
+		return s, s.renameSession(session.ID, title)
+	case "ctrl+c":
+		s.stopRename()
+		return s, textinput.Blink
+	}
+	var cmd tea.Cmd
+	s.renameInput, cmd = s.renameInput.Update(msg)
+	s.updateListItems()
+	return s, cmd
+}
+
+func (s *sessionDialog) stopRename() {
+	s.renaming = -1
+	s.renameInput.Blur()
+	s.query.Focus()
+	s.updateListItems()
+}
+
 func (s *sessionDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width - 12
+
+	s.query.SetWidth(width - 2)
+	inputView := styles.NewStyle().
+		Background(t.BackgroundPanel()).
+		Width(width).
+		Render(s.query.View())
+
 	listView := s.list.View()
 
-	t := theme.CurrentTheme()
+	base := styles.NewStyle().Foreground(t.Text()).Render
+	muted := styles.NewStyle().Background(t.BackgroundElement()).Foreground(t.TextMuted()).Render
 	helpStyle := styles.NewStyle().PaddingLeft(1).PaddingTop(1)
-	helpText := styles.NewStyle().Foreground(t.Text()).Render("x/del")
-	helpText = helpText + styles.NewStyle().Background(t.BackgroundElement()).Foreground(t.TextMuted()).Render(" delete session")
+	helpText := base("ctrl+r") + muted(" rename  ") +
+		base("ctrl+d") + muted(" delete  ") +
+		base("tab") + muted(" sort: "+s.sort.String())
 	helpText = helpStyle.Render(helpText)
 
-	content := strings.Join([]string{listView, helpText}, "\n")
+	content := strings.Join([]string{inputView, listView, s.preview(width), helpText}, "\n")
 
 	return s.modal.Render(content, background)
 }
 
+// preview describes the selected session.
+func (s *sessionDialog) preview(width int) string {
+	t := theme.CurrentTheme()
+	style := styles.NewStyle().
+		Foreground(t.TextMuted()).
+		PaddingLeft(1).
+		PaddingTop(1).
+		Width(width)
+
+	_, idx := s.list.GetSelectedItem()
+	if idx < 0 || idx >= len(s.sessions) {
+		return style.Render("\n")
+	}
+	session := s.sessions[idx]
+	lines := []string{
+		fmt.Sprintf("Created %s, updated %s",
+			formatSessionTime(session.Time.Created),
+			formatSessionTime(session.Time.Updated),
+		),
+	}
+	if stats, ok := s.stats[session.ID]; ok {
+		line := fmt.Sprintf("%d messages, $%.2f", stats.Messages, stats.Cost)
+		if len(stats.Models) > 0 {
+			line += ", " + strings.Join(stats.Models, ", ")
+		}
+		lines = append(lines, line)
+	} else {
+		lines = append(lines, "Loading...")
+	}
+	for i, line := range lines {
+		lines[i] = truncate.StringWithTail(line, uint(width-2), "...")
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+func formatSessionTime(millis float64) string {
+	return time.UnixMilli(int64(millis)).Local().Format("02 Jan 2006 03:04 PM")
+}
+
+// refresh filters and orders the sessions again, keeping the selected one
+// selected.
+func (s *sessionDialog) refresh() {
+	selected := ""
+	if _, idx := s.list.GetSelectedItem(); idx >= 0 && idx < len(s.sessions) {
+		selected = s.sessions[idx].ID
+	}
+
+	sessions := slices.Clone(s.all)
+	switch s.sort {
+	case sortRecent:
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return sessions[i].Time.Updated > sessions[j].Time.Updated
+		})
+	case sortCost:
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return s.stats[sessions[i].ID].Cost > s.stats[sessions[j].ID].Cost
+		})
+	case sortName:
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return strings.ToLower(sessions[i].Title) < strings.ToLower(sessions[j].Title)
+		})
+	}
+
+	if query := s.query.Value(); query != "" {
+		// search results are ranked by how well they match, without nesting
+		titles := make([]string, len(sessions))
+		for i, sess := range sessions {
+			titles[i] = sess.Title
+		}
+		matches := fuzzy.RankFindFold(query, titles)
+		sort.Stable(matches)
+		s.sessions = make([]opencode.Session, 0, len(matches))
+		for _, match := range matches {
+			s.sessions = append(s.sessions, sessions[match.OriginalIndex])
+		}
+		s.depths = make([]int, len(s.sessions))
+	} else {
+		s.sessions, s.depths = sessionTree(sessions)
+	}
+
+	s.updateListItems()
+	index := slices.IndexFunc(s.sessions, func(sess opencode.Session) bool { return sess.ID == selected })
+	s.list.SetSelectedIndex(max(index, 0))
+}
+
 func (s *sessionDialog) updateListItems() {
 	_, currentIdx := s.list.GetSelectedItem()
 
@@ -167,14 +430,83 @@ func (s *sessionDialog) updateListItems() {
 	for i, sess := range s.sessions {
 		item := sessionItem{
 			title:              sess.Title,
+			depth:              s.depths[i],
 			isDeleteConfirming: s.deleteConfirmation == i,
 		}
+		if s.renaming == i {
+			item.rename = s.renameInput.View()
+		}
 		items = append(items, item)
 	}
 	s.list.SetItems(items)
 	s.list.SetSelectedIndex(currentIdx)
 }
 
+// loadSelectedStats loads the stats of the selected session for the preview.
+func (s *sessionDialog) loadSelectedStats() tea.Cmd {
+	_, idx := s.list.GetSelectedItem()
+	if idx < 0 || idx >= len(s.sessions) {
+		return nil
+	}
+	return s.loadStats(s.sessions[idx : idx+1])
+}
+
+// statsConcurrency bounds the number of sessions whose stats are loaded at
+// once.
+const statsConcurrency = 8
+
+// loadStats loads the stats of the sessions that don't have them yet.
+func (s *sessionDialog) loadStats(sessions []opencode.Session) tea.Cmd {
+	var ids []string
+	for _, sess := range sessions {
+		if _, ok := s.stats[sess.ID]; ok || s.loading[sess.ID] {
+			continue
+		}
+		s.loading[sess.ID] = true
+		ids = append(ids, sess.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		loaded := make(sessionStatsMsg, len(ids))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		// sorting by cost needs every session, so don't fetch them one by
+		// one, but don't flood the server either
+		limit := make(chan struct{}, statsConcurrency)
+		for _, id := range ids {
+			wg.Add(1)
+			limit <- struct{}{}
+			go func() {
+				defer func() {
+					<-limit
+					wg.Done()
+				}()
+				stats, err := s.app.SessionStats(context.Background(), id)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				loaded[id] = stats
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		return loaded
+	}
+}
+
+func (s *sessionDialog) renameSession(sessionID string, title string) tea.Cmd {
+	return func() tea.Msg {
+		session, err := s.app.RenameSession(context.Background(), sessionID, title)
+		if err != nil {
+			return toast.NewErrorToast("Failed to rename session: " + err.Error())()
+		}
+		return sessionRenamedMsg{session: *session}
+	}
+}
+
 func (s *sessionDialog) deleteSession(sessionID string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
@@ -189,34 +521,68 @@ func (s *sessionDialog) Close() tea.Cmd {
 	return nil
 }
 
-// NewSessionDialog creates a new session switching dialog
-func NewSessionDialog(app *app.App) SessionDialog {
-	sessions, _ := app.ListSessions(context.Background())
-
-	var filteredSessions []opencode.Session
-	var items []sessionItem
+// sessionTree orders sessions so that task sessions and forks follow the
+// session they came from, keeping the given order among siblings, and returns
+// how deeply each is nested. Sessions whose parent isn't listed are shown at
+// the top level.
+func sessionTree(sessions []opencode.Session) ([]opencode.Session, []int) {
+	ids := make(map[string]bool, len(sessions))
 	for _, sess := range sessions {
-		if sess.ParentID != "" {
-			continue
+		ids[sess.ID] = true
+	}
+	children := make(map[string][]opencode.Session)
+	for _, sess := range sessions {
+		parent := sess.ParentID
+		if fork, ok := app.ForkedFrom(sess); ok {
+			parent = fork.SessionID
 		}
-		filteredSessions = append(filteredSessions, sess)
-		items = append(items, sessionItem{
-			title:              sess.Title,
-			isDeleteConfirming: false,
-		})
+		if !ids[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], sess)
 	}
 
+	ordered := make([]opencode.Session, 0, len(sessions))
+	depths := make([]int, 0, len(sessions))
+	var walk func(parentID string, depth int)
+	walk = func(parentID string, depth int) {
+		for _, sess := range children[parentID] {
+			ordered = append(ordered, sess)
+			depths = append(depths, depth)
+			walk(sess.ID, depth+1)
+		}
+	}
+	walk("", 0)
+	return ordered, depths
+}
+
+// NewSessionDialog creates a new session switching dialog, searching for
+// the given query
+func NewSessionDialog(app *app.App, search string) SessionDialog {
+	sessions, _ := app.ListSessions(context.Background())
+
+	query := createTextInput(nil)
+	query.Placeholder = "Search sessions"
+	query.SetValue(search)
+	renameInput := createTextInput(nil)
+	renameInput.Blur()
+
 	// Create a generic list component
 	listComponent := list.NewListComponent(
-		items,
+		[]sessionItem{},
 		10, // maxVisibleSessions
 		"No sessions available",
-		true, // useAlphaNumericKeys
+		false, // letters go to the search
 	)
 	listComponent.SetMaxWidth(layout.Current.Container.Width - 12)
 
-	return &sessionDialog{
-		sessions:           filteredSessions,
+	dialog := &sessionDialog{
+		all:                sessions,
+		stats:              make(sessionStatsMsg),
+		loading:            make(map[string]bool),
+		query:              query,
+		renameInput:        renameInput,
+		renaming:           -1,
 		list:               listComponent,
 		app:                app,
 		deleteConfirmation: -1,
@@ -225,4 +591,6 @@ func NewSessionDialog(app *app.App) SessionDialog {
 			modal.WithMaxWidth(layout.Current.Container.Width-8),
 		),
 	}
+	dialog.refresh()
+	return dialog
 }
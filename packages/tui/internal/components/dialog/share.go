@@ -0,0 +1,147 @@
+package dialog
+
+import (
+	"context"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/components/qr"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+)
+
+// ShareDialog interface for the session sharing dialog
+type ShareDialog interface {
+	layout.Modal
+}
+
+type shareDialog struct {
+	app   *app.App
+	modal *modal.Modal
+	// pending is set while the session is being shared or unshared.
+	pending bool
+	err     error
+}
+
+// Init shares the session if it has no link yet.
+func (s *shareDialog) Init() tea.Cmd {
+	if s.app.Session.Share.URL != "" {
+		return nil
+	}
+	s.pending = true
+	return s.app.ShareSession(context.Background())
+}
+
+func (s *shareDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case app.ShareChangedMsg:
+		s.pending, s.err = false, msg.Err
+	case tea.KeyPressMsg:
+		if s.pending {
+			return s, nil
+		}
+		url := s.app.Session.Share.URL
+		switch msg.String() {
+		case "c":
+			if url != "" {
+				return s, tea.Batch(tea.SetClipboard(url), toast.NewSuccessToast("Share URL copied to clipboard!"))
+			}
+		case "u":
+			if url != "" {
+				s.pending, s.err = true, nil
+				return s, s.app.UnshareSession(context.Background())
+			}
+		case "enter":
+			if url == "" {
+				s.pending, s.err = true, nil
+				return s, s.app.ShareSession(context.Background())
+			}
+		}
+	}
+	return s, nil
+}
+
+// code renders the share URL as a QR code, unless it doesn't fit on screen.
+func (s *shareDialog) code(url string) (string, bool) {
+	code, size, err := qr.Generate(url)
+	if err != nil || code == "" {
+		return "", false
+	}
+	// The code takes two rows of modules per line, plus the quiet zone
+	// around it, the text below it and the modal frame.
+	if (size+1)/2+14 > layout.Current.Viewport.Height || size+8 > layout.Current.Container.Width-14 {
+		return "", false
+	}
+	t := theme.CurrentTheme()
+	return styles.NewStyle().
+		Background(t.Background()).
+		Padding(1, 4).
+		Render(strings.TrimSuffix(code, "\n")), true
+}
+
+func (s *shareDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	textStyle := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement())
+	mutedStyle := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundElement())
+	successStyle := styles.NewStyle().Foreground(t.Success()).Background(t.BackgroundElement())
+	errorStyle := styles.NewStyle().Foreground(t.Error()).Background(t.BackgroundElement())
+
+	url := s.app.Session.Share.URL
+	var lines []string
+	var helpText string
+	switch {
+	case s.pending && url == "":
+		lines = append(lines, mutedStyle.Render("Creating a shareable link…"))
+	case s.pending:
+		lines = append(lines, mutedStyle.Render("Removing the shareable link…"))
+	case url == "":
+		lines = append(lines, mutedStyle.Render("This session is not shared."))
+		helpText = textStyle.Render("enter") + mutedStyle.Render(" share  ")
+	default:
+		if code, ok := s.code(url); ok {
+			lines = append(lines, code, "")
+		} else {
+			lines = append(lines, mutedStyle.Render("Enlarge the terminal to show a QR code."), "")
+		}
+		lines = append(lines,
+			successStyle.Render("● ")+textStyle.Render("Shared")+mutedStyle.Render(", anyone with the link can view this session"),
+			textStyle.Render(url),
+		)
+		helpText = textStyle.Render("c") + mutedStyle.Render(" copy  ") +
+			textStyle.Render("u") + mutedStyle.Render(" unshare  ")
+	}
+	if s.err != nil {
+		lines = append(lines, "", errorStyle.Render(s.err.Error()))
+	}
+	helpText += textStyle.Render("esc") + mutedStyle.Render(" close")
+	lines = append(lines, "", helpText)
+
+	content := lipgloss.JoinVertical(lipgloss.Center, lines...)
+	content = styles.NewStyle().
+		Width(layout.Current.Container.Width - 14).
+		Align(lipgloss.Center).
+		PaddingLeft(1).
+		Render(content)
+	return s.modal.Render(content, background)
+}
+
+func (s *shareDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewShareDialog shows the share link of the current session as a QR code
+// for phones, and lets the user copy it again or unshare the session.
+func NewShareDialog(app *app.App) ShareDialog {
+	return &shareDialog{
+		app: app,
+		modal: modal.New(
+			modal.WithTitle("Share session"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+}
@@ -0,0 +1,228 @@
+package dialog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/viewport"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// UsageDialog interface for the token and cost usage dialog
+type UsageDialog interface {
+	layout.Modal
+}
+
+type usageDialog struct {
+	app      *app.App
+	modal    *modal.Modal
+	viewport viewport.Model
+	session  app.Usage
+	project  *app.Usage
+	err      error
+	// showProject switches from the current session to the whole project.
+	showProject bool
+}
+
+func (u *usageDialog) Init() tea.Cmd {
+	return u.app.ProjectUsage(context.Background())
+}
+
+func (u *usageDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case app.ProjectUsageMsg:
+		u.project, u.err = &msg.Usage, msg.Err
+		u.refresh()
+		return u, nil
+	case tea.WindowSizeMsg:
+		u.refresh()
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "tab", "shift+tab":
+			u.showProject = !u.showProject
+			u.refresh()
+			u.viewport.GotoTop()
+			return u, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	u.viewport, cmd = u.viewport.Update(msg)
+	return u, cmd
+}
+
+// width is the width of the dialog content, inside the modal padding.
+func (u *usageDialog) width() int {
+	return layout.Current.Container.Width - 14
+}
+
+func (u *usageDialog) refresh() {
+	var lines []string
+	switch {
+	case !u.showProject:
+		lines = u.sessionLines()
+	case u.err != nil:
+		lines = []string{"Failed to load sessions: " + u.err.Error()}
+	case u.project == nil:
+		lines = []string{"Loading sessions…"}
+	default:
+		lines = u.projectLines()
+	}
+	t := theme.CurrentTheme()
+	style := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement()).Width(u.width())
+	for i, line := range lines {
+		lines[i] = style.Render(line)
+	}
+	u.viewport.SetWidth(u.width())
+	u.viewport.SetHeight(min(len(lines), max(layout.Current.Viewport.Height-12, 5)))
+	u.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// summaryLines describe the totals of usage.
+func summaryLines(usage app.Usage, scope string) []string {
+	tokens := usage.Tokens
+	return []string{
+		fmt.Sprintf("%s: $%.2f over %d turn(s), %.0f%% cache hits",
+			scope, usage.Cost, len(usage.Turns), tokens.CacheHitRatio()*100),
+		fmt.Sprintf("Input %s  Output %s  Reasoning %s  Cache read %s  Cache write %s",
+			util.FormatTokens(tokens.Input),
+			util.FormatTokens(tokens.Output),
+			util.FormatTokens(tokens.Reasoning),
+			util.FormatTokens(tokens.CacheRead),
+			util.FormatTokens(tokens.CacheWrite),
+		),
+	}
+}
+
+// tokenColumns formats token counts as the columns of a table row.
+func tokenColumns(tokens app.TokenUsage, cost float64) string {
+	return fmt.Sprintf("%7s %7s %7s %7s %7s %8s",
+		util.FormatTokens(tokens.Input),
+		util.FormatTokens(tokens.Output),
+		util.FormatTokens(tokens.Reasoning),
+		util.FormatTokens(tokens.CacheRead),
+		util.FormatTokens(tokens.CacheWrite),
+		fmt.Sprintf("$%.3f", cost),
+	)
+}
+
+// tokenHeader is the header of the token columns.
+var tokenHeader = fmt.Sprintf("%7s %7s %7s %7s %7s %8s", "Input", "Output", "Reason", "Read", "Write", "Cost")
+
+// modelColumn pads or cuts a model name to the width left by the other
+// columns of a table.
+func (u *usageDialog) modelColumn(model string, other int) string {
+	width := max(u.width()-other-len(tokenHeader)-2, 8)
+	return fmt.Sprintf("%-*s", width, ansi.Truncate(model, width, "…"))
+}
+
+func (u *usageDialog) sessionLines() []string {
+	usage := u.session
+	if len(usage.Turns) == 0 {
+		return []string{"Nothing has been used in this session yet."}
+	}
+	lines := summaryLines(usage, "Session")
+
+	var sizes []float64
+	for _, turn := range usage.Turns {
+		sizes = append(sizes, turn.Context)
+	}
+	current := util.FormatTokens(sizes[len(sizes)-1])
+	lines = append(lines, "",
+		"Context "+sparkline(sizes, u.width()-len("Context ")-len(current)-1)+" "+current,
+		"",
+		fmt.Sprintf("%3s %s %s", "#", u.modelColumn("Model", 4), tokenHeader),
+	)
+	for i, turn := range usage.Turns {
+		lines = append(lines, fmt.Sprintf("%3d %s %s", i+1, u.modelColumn(turn.Model, 4), tokenColumns(turn.Tokens, turn.Cost)))
+	}
+	return lines
+}
+
+func (u *usageDialog) projectLines() []string {
+	usage := *u.project
+	lines := summaryLines(usage, fmt.Sprintf("%d session(s)", usage.Sessions))
+	lines = append(lines, "",
+		fmt.Sprintf("%s %5s %s", u.modelColumn("Model", 6), "Turns", tokenHeader),
+	)
+	for _, model := range usage.Models {
+		lines = append(lines, fmt.Sprintf("%s %5d %s", u.modelColumn(model.Model, 6), model.Turns, tokenColumns(model.Tokens, model.Cost)))
+	}
+	return lines
+}
+
+// sparkline draws values as a row of bars at most width wide, sampling them
+// evenly when there are more.
+func sparkline(values []float64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		sampled := make([]float64, width)
+		for i := range sampled {
+			sampled[i] = values[(i+1)*len(values)/width-1]
+		}
+		values = sampled
+	}
+	top := slices.Max(values)
+	var b strings.Builder
+	for _, value := range values {
+		level := 0
+		if top > 0 {
+			level = int(value / top * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+func (u *usageDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	base := styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement())
+	muted := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundElement())
+	active := styles.NewStyle().Foreground(t.Primary()).Background(t.BackgroundElement()).Bold(true)
+
+	sessionTab, projectTab := active.Render("Session"), muted.Render("Project")
+	if u.showProject {
+		sessionTab, projectTab = muted.Render("Session"), active.Render("Project")
+	}
+	tabs := sessionTab + muted.Render("  ") + projectTab
+
+	helpText := base.Render("tab") + muted.Render(" session/project  ") +
+		base.Render("↑↓") + muted.Render(" scroll")
+
+	content := strings.Join([]string{tabs, "", u.viewport.View(), "", helpText}, "\n")
+	content = styles.NewStyle().PaddingLeft(1).Render(content)
+	return u.modal.Render(content, background)
+}
+
+func (u *usageDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewUsageDialog breaks down the tokens and cost of the current session by
+// turn, and of all the sessions of the project by model.
+func NewUsageDialog(app *app.App) UsageDialog {
+	dialog := &usageDialog{
+		app:      app,
+		session:  app.SessionUsage(),
+		viewport: viewport.New(),
+		modal: modal.New(
+			modal.WithTitle("Usage"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+	dialog.refresh()
+	return dialog
+}
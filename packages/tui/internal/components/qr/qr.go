@@ -23,7 +23,7 @@ func Generate(text string) (string, int, error) {
 	}
 
 	// Create lipgloss style for QR code with theme colors
-	qrStyle := styles.NewStyleWithColors(t.Text(), t.Background())
+	qrStyle := styles.NewStyle().Foreground(t.Text()).Background(t.Background())
 
 	var result strings.Builder
 
@@ -43,13 +43,17 @@ func Generate(text string) (string, int, error) {
 		result.WriteString(qrStyle.Render(line.String()) + "\n")
 	}
 
-	// add lower border when required (only required when QR size is odd)
+	// add the last row on its own when the QR size is odd
 	if code.Size%2 == 1 {
-		var borderLine strings.Builder
-		for range code.Size {
-			borderLine.WriteRune('▀')
+		var lastLine strings.Builder
+		for x := 0; x < code.Size; x += 1 {
+			if code.Black(x, code.Size-1) {
+				lastLine.WriteRune('▀')
+			} else {
+				lastLine.WriteRune(' ')
+			}
 		}
-		result.WriteString(qrStyle.Render(borderLine.String()) + "\n")
+		result.WriteString(qrStyle.Render(lastLine.String()) + "\n")
 	}
 
 	return result.String(), code.Size, nil
@@ -2,13 +2,14 @@ package status
 
 import (
 	"fmt"
-	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
 	"github.com/sst/opencode/internal/app"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
 )
 
 type StatusComponent interface {
@@ -17,8 +18,9 @@ type StatusComponent interface {
 }
 
 type statusComponent struct {
-	app   *app.App
-	width int
+	app        *app.App
+	width      int
+	connection app.ConnectionStatusMsg
 }
 
 func (m statusComponent) Init() tea.Cmd {
@@ -30,6 +32,9 @@ func (m statusComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		return m, nil
+	case app.ConnectionStatusMsg:
+		m.connection = msg
+		return m, nil
 	}
 	return m, nil
 }
@@ -48,28 +53,43 @@ func (m statusComponent) logo() string {
 		Render(open + code + version)
 }
 
-func formatTokensAndCost(tokens float64, contextWindow float64, cost float64) string {
-	// Format tokens in human-readable format (e.g., 110K, 1.2M)
-	var formattedTokens string
-	switch {
-	case tokens >= 1_000_000:
-		formattedTokens = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
-	case tokens >= 1_000:
-		formattedTokens = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
-	default:
-		formattedTokens = fmt.Sprintf("%d", int(tokens))
+func (m statusComponent) connectionStatus() string {
+	if m.connection.Connected {
+		return ""
 	}
-
-	// Remove .0 suffix if present
-	if strings.HasSuffix(formattedTokens, ".0K") {
-		formattedTokens = strings.Replace(formattedTokens, ".0K", "K", 1)
+	t := theme.CurrentTheme()
+	text := "● reconnecting"
+	if m.connection.Attempt > 1 {
+		text = fmt.Sprintf("%s (attempt %d)", text, m.connection.Attempt)
 	}
-	if strings.HasSuffix(formattedTokens, ".0M") {
-		formattedTokens = strings.Replace(formattedTokens, ".0M", "M", 1)
+	return styles.NewStyle().
+		Foreground(t.Warning()).
+		Background(t.BackgroundElement()).
+		Padding(0, 1).
+		Render(text)
+}
+
+func (m statusComponent) compactionStatus() string {
+	if !m.app.IsCompacting() {
+		return ""
 	}
+	t := theme.CurrentTheme()
+	elapsed := time.Since(m.app.Compaction.Started).Truncate(time.Second)
+	return styles.NewStyle().
+		Foreground(t.Primary()).
+		Background(t.BackgroundElement()).
+		Padding(0, 1).
+		Render(fmt.Sprintf("● compacting session (%s)", elapsed))
+}
+
+func formatTokensAndCost(tokens float64, contextWindow float64, cost float64, budget float64) string {
+	formattedTokens := util.FormatTokens(tokens)
 
 	// Format cost with $ symbol and 2 decimal places
 	formattedCost := fmt.Sprintf("$%.2f", cost)
+	if budget > 0 {
+		formattedCost += fmt.Sprintf("/$%.2f", budget)
+	}
 	percentage := (float64(tokens) / float64(contextWindow)) * 100
 
 	return fmt.Sprintf("Context: %s (%d%%), Cost: %s", formattedTokens, int(percentage), formattedCost)
@@ -77,7 +97,8 @@ func formatTokensAndCost(tokens float64, contextWindow float64, cost float64) st
 
 func (m statusComponent) View() string {
 	t := theme.CurrentTheme()
-	if m.app.Session.ID == "" {
+	connection := m.connectionStatus()
+	if m.app.Session.ID == "" && connection == "" {
 		return styles.NewStyle().
 			Background(t.Background()).
 			Width(m.width).
@@ -93,44 +114,28 @@ func (m statusComponent) View() string {
 		Padding(0, 1).
 		Render(m.app.Info.Path.Cwd)
 
+	compaction := m.compactionStatus()
 	sessionInfo := ""
 	if m.app.Session.ID != "" {
-		tokens := float64(0)
-		cost := float64(0)
+		tokens, cost := m.app.ContextUsage()
 		contextWindow := m.app.Model.Limit.Context
 
-		for _, message := range m.app.Messages {
-			cost += message.Metadata.Assistant.Cost
-			usage := message.Metadata.Assistant.Tokens
-			if usage.Output > 0 {
-				if message.Metadata.Assistant.Summary {
-					tokens = usage.Output
-					continue
-				}
-				tokens = (usage.Input +
-					usage.Cache.Write +
-					usage.Cache.Read +
-					usage.Output +
-					usage.Reasoning)
-			}
-		}
-
 		sessionInfo = styles.NewStyle().
 			Foreground(t.TextMuted()).
 			Background(t.BackgroundElement()).
 			Padding(0, 1).
-			Render(formatTokensAndCost(tokens, contextWindow, cost))
+			Render(formatTokensAndCost(tokens, contextWindow, cost, m.app.Budgets.Session.Limit))
 	}
 
 	// diagnostics := styles.Padded().Background(t.BackgroundElement()).Render(m.projectDiagnostics())
 
 	space := max(
 		0,
-		m.width-lipgloss.Width(logo)-lipgloss.Width(cwd)-lipgloss.Width(sessionInfo),
+		m.width-lipgloss.Width(logo)-lipgloss.Width(cwd)-lipgloss.Width(connection)-lipgloss.Width(compaction)-lipgloss.Width(sessionInfo),
 	)
 	spacer := styles.NewStyle().Background(t.BackgroundPanel()).Width(space).Render("")
 
-	status := logo + cwd + spacer + sessionInfo
+	status := logo + cwd + spacer + connection + compaction + sessionInfo
 
 	blank := styles.NewStyle().Background(t.Background()).Width(m.width).Render("")
 	return blank + "\n" + status
@@ -140,6 +145,7 @@ func NewStatusCmp(app *app.App) StatusComponent {
 	statusComponent := &statusComponent{
 		app: app,
 	}
+	statusComponent.connection.Connected = true
 
 	return statusComponent
 }
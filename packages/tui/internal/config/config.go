@@ -16,6 +16,26 @@ type ModelUsage struct {
 	LastUsed   time.Time `toml:"last_used"`
 }
 
+// Budget limits how much may be spent. Passing Warn shows a warning; prompts
+// past Limit need confirming. Zero disables either.
+type Budget struct {
+	Warn  float64 `json:"warn"`
+	Limit float64 `json:"limit"`
+}
+
+// Budgets are the cost budgets set under "budget" in the opencode config.
+type Budgets struct {
+	Session Budget `json:"session"`
+	Daily   Budget `json:"daily"`
+}
+
+// Notifications configures how the user is told about sessions that
+// finish or fail while the terminal is unfocused.
+type Notifications struct {
+	Method  string `json:"method"`
+	Command string `json:"command"`
+}
+
 type State struct {
 	Theme              string       `toml:"theme"`
 	Provider           string       `toml:"provider"`
@@ -30,3 +30,9 @@ type Modal interface {
 	Render(background string) string
 	Close() tea.Cmd
 }
+
+// Confirmer is implemented by modals that can wait for the user to confirm
+// an action. Escape then cancels the confirmation instead of the modal.
+type Confirmer interface {
+	Confirming() bool
+}
@@ -0,0 +1,181 @@
+// Package recorder captures the traffic between the TUI and the opencode
+// server to a JSONL file, and plays such a file back without a server.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+const (
+	KindApp     = "app"
+	KindRequest = "request"
+	KindEvent   = "event"
+)
+
+// Entry is a single line of a recording.
+type Entry struct {
+	Time     time.Time       `json:"time"`
+	Kind     string          `json:"kind"`
+	App      *opencode.App   `json:"app,omitempty"`
+	Method   string          `json:"method,omitempty"`
+	Path     string          `json:"path,omitempty"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Status   int             `json:"status,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Event    json.RawMessage `json:"event,omitempty"`
+}
+
+// Recorder appends every request made to the server, with its response, and
+// every event received from it to a file.
+type Recorder struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// New creates the recording at path, starting with the app info so that it
+// can be replayed on its own.
+func New(path string, appInfo opencode.App) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{file: file, enc: json.NewEncoder(file)}
+	r.write(Entry{Kind: KindApp, App: &appInfo})
+	return r, nil
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+func (r *Recorder) write(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if err := r.enc.Encode(entry); err != nil {
+		slog.Error("Failed to write recording", "error", err)
+	}
+}
+
+// Middleware records requests going through the client it is installed on,
+// see option.WithMiddleware. The event stream is recorded as it is read.
+func (r *Recorder) Middleware(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		requestBody = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := next(req)
+	if err != nil {
+		return resp, err
+	}
+
+	path := requestPath(req)
+	if isEventStream(req) {
+		resp.Body = &eventTee{body: resp.Body, recorder: r}
+		return resp, nil
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	r.write(Entry{
+		Kind:     KindRequest,
+		Method:   req.Method,
+		Path:     path,
+		Request:  rawBody(requestBody),
+		Status:   resp.StatusCode,
+		Response: rawBody(responseBody),
+	})
+	return resp, nil
+}
+
+// isEventStream reports whether req subscribes to the events of the server,
+// whose base URL may have a path of its own.
+func isEventStream(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/event")
+}
+
+func requestPath(req *http.Request) string {
+	if req.URL.RawQuery != "" {
+		return req.URL.Path + "?" + req.URL.RawQuery
+	}
+	return req.URL.Path
+}
+
+// rawBody keeps JSON bodies as they are and stores anything else as a string.
+func rawBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// eventTee records each server-sent event as it passes through.
+type eventTee struct {
+	body     io.ReadCloser
+	recorder *Recorder
+	pending  []byte
+	data     []byte
+}
+
+func (t *eventTee) Read(p []byte) (int, error) {
+	n, err := t.body.Read(p)
+	t.pending = append(t.pending, p[:n]...)
+	for {
+		i := bytes.IndexByte(t.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(t.pending[:i], []byte("\r"))
+		switch {
+		case len(line) == 0:
+			if len(t.data) > 0 {
+				t.recorder.write(Entry{Kind: KindEvent, Event: t.data})
+				t.data = nil
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			if len(t.data) > 0 {
+				t.data = append(t.data, '\n')
+			}
+			data := bytes.TrimPrefix(line[len("data:"):], []byte(" "))
+			t.data = append(t.data, data...)
+		}
+		t.pending = append(t.pending[:0], t.pending[i+1:]...)
+	}
+	return n, err
+}
+
+func (t *eventTee) Close() error {
+	return t.body.Close()
+}
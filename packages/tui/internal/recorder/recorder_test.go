@@ -0,0 +1,170 @@
+package recorder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := testserver.New(t)
+	session := testserver.NewSession("ses_recorded", "Recorded session")
+	server.AddSession(session)
+	messages := []opencode.Message{
+		testserver.UserMessage("msg_1", session.ID, "Hello"),
+		testserver.AssistantMessage("msg_2", session.ID, "Hi", false),
+		testserver.AssistantMessage("msg_2", session.ID, "Hi there", true),
+	}
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	rec, err := New(path, testserver.App())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := opencode.NewClient(
+		option.WithBaseURL(server.URL),
+		option.WithMaxRetries(0),
+		option.WithMiddleware(rec.Middleware),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := client.Event.ListStreaming(ctx)
+	// the greeting tells the subscription is live
+	if !stream.Next() {
+		t.Fatalf("event stream closed: %v", stream.Err())
+	}
+	if _, err := client.Session.List(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range messages {
+		time.Sleep(50 * time.Millisecond)
+		server.AddMessage(message)
+		if !stream.Next() {
+			t.Fatalf("event stream closed: %v", stream.Err())
+		}
+	}
+	cancel()
+	stream.Close()
+	rec.Close()
+
+	recording, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recording.App == nil || recording.App.Path.Cwd != testserver.App().Path.Cwd {
+		t.Errorf("recording app = %+v, want the recorded app", recording.App)
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	local, err := recording.LocalApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if local.Path.Root != testserver.App().Path.Root || local.Path.Data == recording.App.Path.Data ||
+		local.Path.State == recording.App.Path.State || local.Path.Config == recording.App.Path.Config {
+		t.Errorf("local app = %+v, want the recorded project with local directories", local.Path)
+	}
+	if replayed, ok := recording.Session(); !ok || replayed.ID != session.ID {
+		t.Errorf("recording session = %q, want %q", replayed.ID, session.ID)
+	}
+
+	replay := opencode.NewClient(
+		option.WithBaseURL("http://replay.invalid"),
+		option.WithMaxRetries(0),
+		option.WithHTTPClient(recording.Client()),
+	)
+	sessions, err := replay.Session.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions == nil || len(*sessions) != 1 || (*sessions)[0].ID != session.ID {
+		t.Errorf("replayed sessions = %+v, want the recorded session", sessions)
+	}
+
+	var span time.Duration
+	var first time.Time
+	for _, entry := range recording.entries {
+		if entry.Kind != KindEvent {
+			continue
+		}
+		if first.IsZero() {
+			first = entry.Time
+		}
+		span = entry.Time.Sub(first)
+	}
+
+	const speed = 10
+	var replayed []string
+	start := time.Now()
+	recording.Play(context.Background(), func(msg tea.Msg) {
+		if evt, ok := msg.(opencode.EventListResponseEventMessageUpdated); ok {
+			info := evt.Properties.Info
+			replayed = append(replayed, info.ID+" "+string(info.Role))
+		}
+	}, speed)
+	elapsed := time.Since(start)
+
+	var want []string
+	for _, message := range messages {
+		want = append(want, message.ID+" "+string(message.Role))
+	}
+	if !slices.Equal(replayed, want) {
+		t.Errorf("replayed messages = %v, want %v", replayed, want)
+	}
+	if elapsed < span/speed || elapsed >= span {
+		t.Errorf("replay took %v, want about %v for %v recorded at %vx", elapsed, span/speed, span, speed)
+	}
+}
+
+func TestRecordBehindBasePath(t *testing.T) {
+	server := testserver.New(t)
+	prefixed := httptest.NewServer(http.StripPrefix("/api", server.Server.Config.Handler))
+	t.Cleanup(prefixed.Close)
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	rec, err := New(path, testserver.App())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := opencode.NewClient(
+		option.WithBaseURL(prefixed.URL+"/api/"),
+		option.WithMaxRetries(0),
+		option.WithMiddleware(rec.Middleware),
+	)
+	// a stream read to its end before being handed on would never start
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream := client.Event.ListStreaming(ctx)
+	if !stream.Next() {
+		t.Fatalf("event stream closed: %v", stream.Err())
+	}
+	server.AddSession(testserver.NewSession("ses_1", "Session"))
+	server.AddMessage(testserver.UserMessage("msg_1", "ses_1", "Hello"))
+	if !stream.Next() {
+		t.Fatalf("event stream closed: %v", stream.Err())
+	}
+	stream.Close()
+	rec.Close()
+
+	recording, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events int
+	for _, entry := range recording.entries {
+		if entry.Kind == KindEvent {
+			events++
+		}
+	}
+	if events == 0 {
+		t.Error("no event was recorded")
+	}
+}
@@ -0,0 +1,213 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Recording is a recording loaded for replay.
+type Recording struct {
+	App     *opencode.App
+	entries []Entry
+
+	mu        sync.Mutex
+	responses map[string][]Entry
+}
+
+// Load reads the recording at path.
+func Load(path string) (*Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := &Recording{responses: make(map[string][]Entry)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		switch entry.Kind {
+		case KindApp:
+			r.App = entry.App
+		case KindRequest:
+			key := entry.Method + " " + entry.Path
+			r.responses[key] = append(r.responses[key], entry)
+		}
+		r.entries = append(r.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// LocalApp returns the recorded app info for rendering the recorded
+// project, with data, state and config directories on this machine. The
+// recorded ones may not exist here, or belong to someone else.
+func (r *Recording) LocalApp() (opencode.App, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return opencode.App{}, err
+	}
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return opencode.App{}, err
+	}
+	appInfo := *r.App
+	appInfo.Path.Data = filepath.Join(cache, "opencode", "replay")
+	appInfo.Path.State = appInfo.Path.Data
+	appInfo.Path.Config = filepath.Join(config, "opencode")
+	return appInfo, nil
+}
+
+// Session returns the session the recorded events belong to.
+func (r *Recording) Session() (opencode.Session, bool) {
+	var sessionID string
+	for _, evt := range r.events() {
+		switch evt := evt.(type) {
+		case opencode.EventListResponseEventSessionUpdated:
+			if sessionID == "" || evt.Properties.Info.ID == sessionID {
+				return evt.Properties.Info, true
+			}
+		case opencode.EventListResponseEventMessageUpdated:
+			if sessionID == "" {
+				sessionID = evt.Properties.Info.Metadata.SessionID
+			}
+		}
+	}
+	if sessionID == "" {
+		return opencode.Session{}, false
+	}
+	return opencode.Session{ID: sessionID}, true
+}
+
+func (r *Recording) events() []opencode.EventListResponseUnion {
+	var events []opencode.EventListResponseUnion
+	for _, entry := range r.entries {
+		if entry.Kind != KindEvent {
+			continue
+		}
+		if evt := decodeEvent(entry); evt != nil {
+			events = append(events, evt)
+		}
+	}
+	return events
+}
+
+func decodeEvent(entry Entry) opencode.EventListResponseUnion {
+	var evt opencode.EventListResponse
+	if err := json.Unmarshal(entry.Event, &evt); err != nil {
+		slog.Warn("Skipping unreadable recorded event", "error", err)
+		return nil
+	}
+	return evt.AsUnion()
+}
+
+// Play sends the recorded events with their original spacing divided by
+// speed, until they run out or ctx is cancelled.
+func (r *Recording) Play(ctx context.Context, send func(tea.Msg), speed float64) {
+	var previous time.Time
+	for _, entry := range r.entries {
+		if entry.Kind != KindEvent {
+			continue
+		}
+		if !previous.IsZero() {
+			delay := time.Duration(float64(entry.Time.Sub(previous)) / speed)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+		previous = entry.Time
+		if evt := decodeEvent(entry); evt != nil {
+			send(evt)
+		}
+	}
+	slog.Info("Replay finished")
+}
+
+// Client returns an http client that answers from the recording instead of
+// the network, for option.WithHTTPClient.
+func (r *Recording) Client() *http.Client {
+	return &http.Client{Transport: roundTripFunc(r.roundTrip)}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// roundTrip serves recorded responses for the same method and path in the
+// order they were recorded, repeating the last one once they run out.
+func (r *Recording) roundTrip(req *http.Request) (*http.Response, error) {
+	path := requestPath(req)
+	if isEventStream(req) {
+		// events are sent by Play, the stream just stays open
+		body, writer := io.Pipe()
+		go func() {
+			<-req.Context().Done()
+			writer.Close()
+		}()
+		return response(req, http.StatusOK, "text/event-stream", body), nil
+	}
+
+	r.mu.Lock()
+	key := req.Method + " " + path
+	queue := r.responses[key]
+	var entry *Entry
+	if len(queue) > 0 {
+		entry = &queue[0]
+		if len(queue) > 1 {
+			r.responses[key] = queue[1:]
+		}
+	}
+	r.mu.Unlock()
+
+	if entry == nil {
+		// a session created while recording was never listed, so it starts
+		// out empty
+		if req.Method == http.MethodGet && strings.HasSuffix(path, "/message") {
+			return response(req, http.StatusOK, "application/json", io.NopCloser(strings.NewReader("[]"))), nil
+		}
+		slog.Warn("No recorded response", "method", req.Method, "path", path)
+		body := fmt.Sprintf(`{"error":"no recorded response for %s"}`, key)
+		return response(req, http.StatusNotFound, "application/json", io.NopCloser(strings.NewReader(body))), nil
+	}
+	return response(req, entry.Status, "application/json", io.NopCloser(bytes.NewReader(entry.Response))), nil
+}
+
+func response(req *http.Request, status int, contentType string, body io.ReadCloser) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{contentType}},
+		Body:       body,
+		Request:    req,
+	}
+}
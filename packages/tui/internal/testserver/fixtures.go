@@ -0,0 +1,172 @@
+package testserver
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sst/opencode-sdk-go"
+)
+
+// Created is the creation time of every fixture, in milliseconds, so that
+// rendered timestamps are stable.
+const Created = 1700000000000
+
+// Root is the project directory fixtures refer to.
+const Root = "/project"
+
+func decode[T any](data string) T {
+	var v T
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		panic(fmt.Sprintf("testserver: invalid fixture: %v", err))
+	}
+	return v
+}
+
+// AppIn describes a project at Root whose data lives in dir.
+func AppIn(dir string) opencode.App {
+	return decode[opencode.App](fmt.Sprintf(`{
+		"git": true,
+		"hostname": "test",
+		"user": "test",
+		"path": {"config": %[1]q, "data": %[1]q, "state": %[1]q, "root": %[2]q, "cwd": %[2]q},
+		"time": {}
+	}`, dir, Root))
+}
+
+// App is AppIn for a throwaway data directory.
+func App() opencode.App {
+	return AppIn("/tmp/opencode-testserver")
+}
+
+func NewSession(id, title string) opencode.Session {
+	return decode[opencode.Session](fmt.Sprintf(
+		`{"id": %q, "title": %q, "version": "test", "time": {"created": %d, "updated": %d}}`,
+		id, title, Created, Created,
+	))
+}
+
+// NewChildSession is the session a subagent of parentID ran in.
+func NewChildSession(id, parentID, title string) opencode.Session {
+	return decode[opencode.Session](fmt.Sprintf(
+		`{"id": %q, "parentID": %q, "title": %q, "version": "test", "time": {"created": %d, "updated": %d}}`,
+		id, parentID, title, Created, Created,
+	))
+}
+
+// NewForkedSession is a fork of sessionID up to messageID.
+func NewForkedSession(id, sessionID, messageID, title string) opencode.Session {
+	return decode[opencode.Session](fmt.Sprintf(
+		`{"id": %q, "forkedFrom": {"sessionID": %q, "messageID": %q}, "title": %q, "version": "test", "time": {"created": %d, "updated": %d}}`,
+		id, sessionID, messageID, title, Created, Created,
+	))
+}
+
+func UserMessage(id, sessionID, text string) opencode.Message {
+	part, _ := json.Marshal(map[string]string{"type": "text", "text": text})
+	return userMessage(id, sessionID, []json.RawMessage{part})
+}
+
+func userMessage(id, sessionID string, parts []json.RawMessage) opencode.Message {
+	data, _ := json.Marshal(parts)
+	return decode[opencode.Message](fmt.Sprintf(`{
+		"id": %q,
+		"role": "user",
+		"parts": %s,
+		"metadata": {"sessionID": %q, "time": {"created": %d}, "tool": {}}
+	}`, id, data, sessionID, Created))
+}
+
+// AssistantMessage is a reply from the test model, completed unless it is
+// still streaming.
+func AssistantMessage(id, sessionID, text string, completed bool) opencode.Message {
+	completedAt := 0
+	if completed {
+		completedAt = Created + 1000
+	}
+	return decode[opencode.Message](fmt.Sprintf(`{
+		"id": %q,
+		"role": "assistant",
+		"parts": [{"type": "text", "text": %q}],
+		"metadata": {
+			"sessionID": %q,
+			"time": {"created": %d, "completed": %d},
+			"tool": {},
+			"assistant": {
+				"modelID": "test-model",
+				"providerID": "test",
+				"cost": 0.01,
+				"path": {"cwd": %q, "root": %q},
+				"system": [],
+				"summary": false,
+				"tokens": {"input": 100, "output": 20, "reasoning": 0, "cache": {"read": 0, "write": 0}}
+			}
+		}
+	}`, id, text, sessionID, Created, completedAt, Root, Root))
+}
+
+// TaskMessage is a completed reply that ran a subagent in childSessionID.
+func TaskMessage(id, sessionID, childSessionID, description string) opencode.Message {
+	return decode[opencode.Message](fmt.Sprintf(`{
+		"id": %q,
+		"role": "assistant",
+		"parts": [
+			{"type": "text", "text": "Let me delegate that."},
+			{"type": "tool-invocation", "toolInvocation": {
+				"state": "result",
+				"toolCallId": "call_task",
+				"toolName": "task",
+				"args": {"description": %q, "prompt": %q},
+				"result": "Done."
+			}}
+		],
+		"metadata": {
+			"sessionID": %q,
+			"time": {"created": %d, "completed": %d},
+			"tool": {
+				"call_task": {"title": %q, "sessionID": %q, "summary": [], "time": {"start": %d, "end": %d}}
+			},
+			"assistant": {
+				"modelID": "test-model",
+				"providerID": "test",
+				"cost": 0.01,
+				"path": {"cwd": %q, "root": %q},
+				"system": [],
+				"summary": false,
+				"tokens": {"input": 100, "output": 20, "reasoning": 0, "cache": {"read": 0, "write": 0}}
+			}
+		}
+	}`, id, description, description, sessionID, Created, Created+1000,
+		description, childSessionID, Created, Created+1000, Root, Root))
+}
+
+// FailedMessage is a reply that ended with the named error, such as
+// MessageOutputLengthError or UnknownError.
+func FailedMessage(id, sessionID, errorName, message string) opencode.Message {
+	return decode[opencode.Message](fmt.Sprintf(`{
+		"id": %q,
+		"role": "assistant",
+		"parts": [{"type": "text", "text": "Let me think."}],
+		"metadata": {
+			"sessionID": %q,
+			"time": {"created": %d, "completed": %d},
+			"tool": {},
+			"error": {"name": %q, "data": {"message": %q}},
+			"assistant": {
+				"modelID": "test-model",
+				"providerID": "test",
+				"cost": 0.01,
+				"path": {"cwd": %q, "root": %q},
+				"system": [],
+				"summary": false,
+				"tokens": {"input": 100, "output": 20, "reasoning": 0, "cache": {"read": 0, "write": 0}}
+			}
+		}
+	}`, id, sessionID, Created, Created+1000, errorName, message, Root, Root))
+}
+
+// Reply is a ChatHandler that answers every prompt with text.
+func Reply(text string) ChatHandler {
+	return func(s *Server, sessionID string, prompt string) {
+		s.AddMessage(AssistantMessage(s.newID("msg"), sessionID, text, true))
+	}
+}
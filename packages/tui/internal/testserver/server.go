@@ -0,0 +1,522 @@
+// Package testserver is an in-process stand-in for the opencode server, for
+// driving the TUI in tests without a real backend.
+package testserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode-sdk-go/option"
+)
+
+// ChatHandler scripts the reply to a chat request. It is called after the
+// user message has been stored and published, and usually replies through
+// AddMessage.
+type ChatHandler func(s *Server, sessionID string, prompt string)
+
+// Server serves the routes the TUI uses from in-memory state. Fields may be
+// set before the first request; use the methods once the TUI is running.
+type Server struct {
+	*httptest.Server
+
+	Config    opencode.Config
+	Providers []opencode.Provider
+	Defaults  map[string]string
+	Files     map[string]string
+	OnChat    ChatHandler
+
+	mu          sync.Mutex
+	sessions    []opencode.Session
+	messages    map[string][]opencode.Message
+	requests    []string
+	subscribers map[chan []byte]struct{}
+	nextID      int
+	overrides   map[string]http.HandlerFunc
+	// queued counts events published but not yet written to their stream,
+	// written those that were.
+	queued  atomic.Int64
+	written atomic.Int64
+}
+
+// New starts a server with a single provider and model and no sessions, it
+// is closed when the test finishes.
+func New(t testing.TB) *Server {
+	s := &Server{
+		Config: decode[opencode.Config](`{"keybinds": {"leader": "ctrl+x"}}`),
+		Providers: []opencode.Provider{
+			decode[opencode.Provider](`{
+				"id": "test",
+				"name": "Test",
+				"env": [],
+				"models": {
+					"test-model": {
+						"id": "test-model",
+						"name": "Test Model",
+						"attachment": true,
+						"cost": {"input": 1, "output": 2},
+						"limit": {"context": 100000, "output": 4096},
+						"options": {},
+						"reasoning": false,
+						"release_date": "2025-01-01",
+						"temperature": true,
+						"tool_call": true
+					}
+				}
+			}`),
+		},
+		Defaults:    map[string]string{"test": "test-model"},
+		Files:       map[string]string{},
+		messages:    map[string][]opencode.Message{},
+		subscribers: map[chan []byte]struct{}{},
+		overrides:   map[string]http.HandlerFunc{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /app", s.handleApp)
+	mux.HandleFunc("POST /app/init", s.handleTrue)
+	mux.HandleFunc("GET /config", s.handleConfig)
+	mux.HandleFunc("GET /config/providers", s.handleProviders)
+	mux.HandleFunc("GET /event", s.handleEvents)
+	mux.HandleFunc("GET /file", s.handleFile)
+	mux.HandleFunc("GET /file/status", s.handleFileStatus)
+	mux.HandleFunc("GET /find/file", s.handleFindFiles)
+	mux.HandleFunc("GET /session", s.handleListSessions)
+	mux.HandleFunc("POST /session", s.handleNewSession)
+	mux.HandleFunc("POST /session/import", s.handleImport)
+	mux.HandleFunc("DELETE /session/{id}", s.handleDeleteSession)
+	mux.HandleFunc("PATCH /session/{id}", s.handleUpdateSession)
+	mux.HandleFunc("POST /session/{id}/abort", s.handleTrue)
+	mux.HandleFunc("POST /session/{id}/init", s.handleTrue)
+	mux.HandleFunc("POST /session/{id}/summarize", s.handleTrue)
+	mux.HandleFunc("POST /session/{id}/revert", s.handleRevert)
+	mux.HandleFunc("POST /session/{id}/unrevert", s.handleUnrevert)
+	mux.HandleFunc("POST /session/{id}/fork", s.handleFork)
+	mux.HandleFunc("POST /session/{id}/share", s.handleShare)
+	mux.HandleFunc("DELETE /session/{id}/share", s.handleUnshare)
+	mux.HandleFunc("GET /session/{id}/message", s.handleListMessages)
+	mux.HandleFunc("POST /session/{id}/message", s.handleChat)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		s.mu.Lock()
+		s.requests = append(s.requests, key)
+		override := s.overrides[key]
+		s.mu.Unlock()
+		if override != nil {
+			override(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns an SDK client talking to the server.
+func (s *Server) Client() *opencode.Client {
+	return opencode.NewClient(
+		option.WithBaseURL(s.URL),
+		option.WithMaxRetries(0),
+	)
+}
+
+// Configure replaces the config the server returns with the given JSON,
+// keeping fields the SDK doesn't know about.
+func (s *Server) Configure(config string) {
+	s.Config = decode[opencode.Config](config)
+}
+
+// Handle replaces the built-in handler for method and path, e.g. to script
+// a failure.
+func (s *Server) Handle(method, path string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[method+" "+path] = handler
+}
+
+// Requests returns every request received so far, as "METHOD /path".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// AddSession stores a session and publishes it.
+func (s *Server) AddSession(session opencode.Session) {
+	s.mu.Lock()
+	s.sessions = append(s.sessions, session)
+	s.mu.Unlock()
+	s.Emit("session.updated", map[string]any{"info": session})
+}
+
+// AddMessage stores a message, replacing one with the same id, and
+// publishes it.
+func (s *Server) AddMessage(message opencode.Message) {
+	s.mu.Lock()
+	sessionID := message.Metadata.SessionID
+	messages := s.messages[sessionID]
+	index := slices.IndexFunc(messages, func(m opencode.Message) bool { return m.ID == message.ID })
+	if index >= 0 {
+		messages[index] = message
+	} else {
+		s.messages[sessionID] = append(messages, message)
+	}
+	s.mu.Unlock()
+	s.Emit("message.updated", map[string]any{"info": message})
+}
+
+// Messages returns the messages stored for a session.
+func (s *Server) Messages(sessionID string) []opencode.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages[sessionID])
+}
+
+// Emit publishes an event to every connected event stream.
+func (s *Server) Emit(eventType string, properties any) {
+	data := fmt.Appendf(nil, `{"type":%q,"properties":%s}`, eventType, marshal(properties))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for subscriber := range s.subscribers {
+		s.queued.Add(1)
+		subscriber <- data
+	}
+}
+
+// EventsWritten returns how many events were written to event streams, and
+// false while some are still waiting to be written.
+func (s *Server) EventsWritten() (int, bool) {
+	return int(s.written.Load()), s.queued.Load() == 0
+}
+
+func (s *Server) newID(prefix string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	return fmt.Sprintf("%s_%03d", prefix, s.nextID)
+}
+
+func (s *Server) handleApp(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, App())
+}
+
+func (s *Server) handleTrue(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, true)
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Config)
+}
+
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{
+		"providers": s.Providers,
+		"default":   s.Defaults,
+	})
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	events := make(chan []byte, 256)
+	s.mu.Lock()
+	s.subscribers[events] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, events)
+		s.queued.Add(-int64(len(events)))
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	// like the real server, greet with an empty event so clients know the
+	// subscription is live
+	fmt.Fprint(w, "data: {}\n\n")
+	w.(http.Flusher).Flush()
+	s.written.Add(1)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-events:
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+			s.written.Add(1)
+			s.queued.Add(-1)
+		}
+	}
+}
+
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	content, ok := s.Files[r.URL.Query().Get("path")]
+	if !ok {
+		http.Error(w, `{"error":"file not found"}`, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]any{"content": content, "type": "raw"})
+}
+
+func (s *Server) handleFileStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, []any{})
+}
+
+func (s *Server) handleFindFiles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	matches := []string{}
+	for path := range s.Files {
+		if strings.Contains(path, query) {
+			matches = append(matches, path)
+		}
+	}
+	slices.Sort(matches)
+	writeJSON(w, matches)
+}
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, s.sessions)
+}
+
+func (s *Server) handleNewSession(w http.ResponseWriter, r *http.Request) {
+	session := NewSession(s.newID("ses"), "New Session")
+	s.AddSession(session)
+	writeJSON(w, session)
+}
+
+func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	index := slices.IndexFunc(s.sessions, func(session opencode.Session) bool { return session.ID == id })
+	if index < 0 {
+		s.mu.Unlock()
+		http.Error(w, `{"error":"session not found"}`, http.StatusNotFound)
+		return
+	}
+	session := s.sessions[index]
+	s.sessions = slices.Delete(s.sessions, index, index+1)
+	delete(s.messages, id)
+	s.mu.Unlock()
+	s.Emit("session.deleted", map[string]any{"info": session})
+	writeJSON(w, true)
+}
+
+func (s *Server) handleUpdateSession(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Title string `json:"title"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	s.updateSession(w, r.PathValue("id"), func(fields map[string]any) {
+		if body.Title != "" {
+			fields["title"] = body.Title
+		}
+	})
+}
+
+func (s *Server) handleRevert(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		MessageID string `json:"messageID"`
+		Part      int    `json:"part"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	s.updateSession(w, r.PathValue("id"), func(fields map[string]any) {
+		fields["revert"] = map[string]any{"messageID": body.MessageID, "part": body.Part}
+	})
+}
+
+func (s *Server) handleUnrevert(w http.ResponseWriter, r *http.Request) {
+	s.updateSession(w, r.PathValue("id"), func(fields map[string]any) {
+		delete(fields, "revert")
+	})
+}
+
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.updateSession(w, id, func(fields map[string]any) {
+		fields["share"] = map[string]any{"url": "https://opencode.ai/s/" + id}
+	})
+}
+
+func (s *Server) handleUnshare(w http.ResponseWriter, r *http.Request) {
+	s.updateSession(w, r.PathValue("id"), func(fields map[string]any) {
+		delete(fields, "share")
+	})
+}
+
+// updateSession applies edit to the JSON fields of a session, publishes it
+// and writes it as the response.
+func (s *Server) updateSession(w http.ResponseWriter, id string, edit func(fields map[string]any)) {
+	s.mu.Lock()
+	index := slices.IndexFunc(s.sessions, func(session opencode.Session) bool { return session.ID == id })
+	if index < 0 {
+		s.mu.Unlock()
+		http.Error(w, `{"error":"session not found"}`, http.StatusNotFound)
+		return
+	}
+	var fields map[string]any
+	json.Unmarshal(marshal(s.sessions[index]), &fields)
+	edit(fields)
+	data, _ := json.Marshal(fields)
+	session := decode[opencode.Session](string(data))
+	s.sessions[index] = session
+	s.mu.Unlock()
+
+	s.Emit("session.updated", map[string]any{"info": session})
+	writeJSON(w, session)
+}
+
+func (s *Server) handleFork(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var body struct {
+		MessageID string `json:"messageID"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	index := slices.IndexFunc(s.sessions, func(session opencode.Session) bool { return session.ID == id })
+	if index < 0 {
+		s.mu.Unlock()
+		http.Error(w, `{"error":"session not found"}`, http.StatusNotFound)
+		return
+	}
+	parent := s.sessions[index]
+	s.mu.Unlock()
+
+	session := NewForkedSession(s.newID("ses"), parent.ID, body.MessageID, "Fork of "+parent.Title)
+	s.AddSession(session)
+	for _, message := range s.Messages(id) {
+		if message.ID > body.MessageID {
+			break
+		}
+		var fields map[string]any
+		json.Unmarshal(marshal(message), &fields)
+		fields["metadata"].(map[string]any)["sessionID"] = session.ID
+		data, _ := json.Marshal(fields)
+		s.AddMessage(decode[opencode.Message](string(data)))
+	}
+	writeJSON(w, session)
+}
+
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Title    string           `json:"title"`
+		Messages []map[string]any `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+
+	session := NewSession(s.newID("ses"), body.Title)
+	s.AddSession(session)
+	for _, fields := range body.Messages {
+		fields["id"] = s.newID("msg")
+		fields["metadata"].(map[string]any)["sessionID"] = session.ID
+		data, _ := json.Marshal(fields)
+		s.AddMessage(decode[opencode.Message](string(data)))
+	}
+	writeJSON(w, session)
+}
+
+func (s *Server) handleListMessages(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Messages(r.PathValue("id")))
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	var body struct {
+		Parts []json.RawMessage `json:"parts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	var prompt []string
+	for _, raw := range body.Parts {
+		var part struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		json.Unmarshal(raw, &part)
+		if part.Type == "text" {
+			prompt = append(prompt, part.Text)
+		}
+	}
+	text := strings.Join(prompt, "\n")
+
+	s.AddMessage(userMessage(s.newID("msg"), sessionID, body.Parts))
+	if s.OnChat != nil {
+		s.OnChat(s, sessionID, text)
+	}
+
+	messages := s.Messages(sessionID)
+	writeJSON(w, messages[len(messages)-1])
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(marshal(v))
+}
+
+// marshal encodes v, preferring the JSON that SDK values were decoded from,
+// since re-encoding them fills unset unions with zero values the client then
+// decodes as present.
+func marshal(v any) json.RawMessage {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return json.RawMessage("null")
+		}
+		return marshal(value.Elem().Interface())
+	case reflect.Struct:
+		if field := value.FieldByName("JSON"); field.IsValid() {
+			if method := field.MethodByName("RawJSON"); method.IsValid() {
+				if raw := method.Call(nil)[0].String(); raw != "" {
+					return json.RawMessage(raw)
+				}
+			}
+		}
+	case reflect.Slice:
+		switch value.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Pointer, reflect.Interface, reflect.Map:
+			items := make([]json.RawMessage, value.Len())
+			for i := range items {
+				items[i] = marshal(value.Index(i).Interface())
+			}
+			data, _ := json.Marshal(items)
+			return data
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() == reflect.String {
+			items := make(map[string]json.RawMessage, value.Len())
+			for iter := value.MapRange(); iter.Next(); {
+				items[iter.Key().String()] = marshal(iter.Value().Interface())
+			}
+			data, _ := json.Marshal(items)
+			return data
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
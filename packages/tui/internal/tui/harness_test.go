@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// timerCmds are the commands that wait for a timer: cursor blinks, spinners,
+// toast expiry and debounces. The harness drops them.
+var timerCmds = []string{
+	"github.com/charmbracelet/bubbletea/v2.Tick.func",
+	"github.com/charmbracelet/bubbletea/v2.Every.func",
+	"github.com/charmbracelet/bubbles/v2/cursor.(*Model).BlinkCmd.func",
+}
+
+func TestMain(m *testing.M) {
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
+
+// harness runs the TUI against a fake server, executing commands in process
+// instead of through a tea.Program so frames can be compared.
+type harness struct {
+	t      *testing.T
+	server *testserver.Server
+	app    *app.App
+	model  tea.Model
+	events chan tea.Msg
+	// received counts the server events dispatched so far.
+	received int
+}
+
+// newHarness starts the TUI on server in a 100x30 terminal.
+func newHarness(t *testing.T, server *testserver.Server) *harness {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	app_, err := app.New(ctx, "test", testserver.AppIn(t.TempDir()), server.Client())
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+
+	h := &harness{
+		t:      t,
+		server: server,
+		app:    app_,
+		model:  NewModel(app_),
+		events: make(chan tea.Msg, 256),
+	}
+	go app_.StreamEvents(ctx, func(msg tea.Msg) {
+		select {
+		case h.events <- msg:
+		case <-ctx.Done():
+		}
+	})
+	// wait for the subscription so that no event is missed
+	select {
+	case <-h.events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream did not connect")
+	}
+
+	h.run(h.model.Init())
+	h.send(tea.WindowSizeMsg{Width: 100, Height: 30})
+	return h
+}
+
+// existingSession adds the session most tests start from to server, with
+// turns alternating between prompts and completed replies.
+func existingSession(server *testserver.Server, turns ...string) opencode.Session {
+	session := testserver.NewSession("ses_existing", "Existing session")
+	server.AddSession(session)
+	for i, text := range turns {
+		id := fmt.Sprintf("msg_%d", i+1)
+		if i%2 == 0 {
+			server.AddMessage(testserver.UserMessage(id, session.ID, text))
+		} else {
+			server.AddMessage(testserver.AssistantMessage(id, session.ID, text, true))
+		}
+	}
+	return session
+}
+
+// newSessionHarness starts the TUI on server with the session from
+// existingSession open.
+func newSessionHarness(t *testing.T, server *testserver.Server, turns ...string) (*harness, opencode.Session) {
+	t.Helper()
+	session := existingSession(server, turns...)
+	h := newHarness(t, server)
+	h.send(app.SessionSelectedMsg(&session))
+	return h, session
+}
+
+// send delivers msg and everything that follows from it, including events
+// published by the server in the meantime.
+func (h *harness) send(msg tea.Msg) {
+	h.dispatch(msg)
+	h.drain()
+}
+
+// drain delivers server events until every event the server has written
+// was handled.
+func (h *harness) drain() {
+	for {
+		select {
+		case msg := <-h.events:
+			h.dispatch(msg)
+			continue
+		default:
+		}
+		if written, ok := h.server.EventsWritten(); ok && written == h.received {
+			return
+		}
+		h.dispatch(<-h.events)
+	}
+}
+
+func (h *harness) dispatch(msg tea.Msg) {
+	switch msg.(type) {
+	case app.ConnectionStatusMsg, app.ReconnectedMsg:
+	case opencode.EventListResponseUnion:
+		h.received++
+	}
+	h.run(h.update(msg))
+}
+
+// update applies msg and renders afterwards like tea.Program does, since
+// components size themselves while rendering.
+func (h *harness) update(msg tea.Msg) tea.Cmd {
+	model, cmd := h.model.Update(msg)
+	h.model = model
+	h.model.(tea.ViewModel).View()
+	return cmd
+}
+
+// typeText sends text one key at a time.
+func (h *harness) typeText(text string) {
+	for _, r := range text {
+		h.dispatch(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+}
+
+// run executes cmd and everything that follows from it one command at a
+// time, like a tea.Program would but without timers.
+func (h *harness) run(cmd tea.Cmd) {
+	if cmd == nil || isTimer(cmd) {
+		return
+	}
+	switch msg := cmd().(type) {
+	case nil:
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			h.run(cmd)
+		}
+	default:
+		// tea.Sequence produces an unexported slice of commands
+		if value := reflect.ValueOf(msg); value.Kind() == reflect.Slice &&
+			value.Type().Elem() == reflect.TypeFor[tea.Cmd]() {
+			for i := range value.Len() {
+				h.run(value.Index(i).Interface().(tea.Cmd))
+			}
+			return
+		}
+		h.run(h.update(msg))
+	}
+}
+
+func isTimer(cmd tea.Cmd) bool {
+	name := runtime.FuncForPC(reflect.ValueOf(cmd).Pointer()).Name()
+	return slices.ContainsFunc(timerCmds, func(prefix string) bool {
+		return strings.HasPrefix(name, prefix)
+	})
+}
+
+// frame returns the current screen without styling or trailing spaces.
+func (h *harness) frame() string {
+	lines := strings.Split(ansi.Strip(h.model.(tea.ViewModel).View()), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \u00a0")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// assertGolden compares the current frame with testdata/<name>.golden,
+// rewriting it when run with -update.
+func (h *harness) assertGolden(name string) {
+	h.t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	frame := h.frame()
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			h.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(frame), 0644); err != nil {
+			h.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("failed to read golden file, run with -update to create it: %v", err)
+	}
+	if frame != string(want) {
+		h.t.Errorf("frame does not match %s\n--- got ---\n%s\n--- want ---\n%s", path, frame, want)
+	}
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
@@ -11,6 +12,7 @@ import (
 	"github.com/charmbracelet/bubbles/v2/key"
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/muesli/reflow/truncate"
 
 	"github.com/sst/opencode-sdk-go"
 	"github.com/sst/opencode/internal/app"
@@ -64,6 +66,18 @@ type appModel struct {
 	fileViewerStart      int
 	fileViewerEnd        int
 	fileViewerHit        bool
+	initialPrompt        string
+	unfocused            bool
+}
+
+// Option configures the model returned by NewModel
+type Option func(*appModel)
+
+// WithInitialPrompt sends the given prompt as soon as the app has started
+func WithInitialPrompt(prompt string) Option {
+	return func(a *appModel) {
+		a.initialPrompt = prompt
+	}
 }
 
 func (a appModel) Init() tea.Cmd {
@@ -73,7 +87,17 @@ func (a appModel) Init() tea.Cmd {
 	if !util.IsWsl() {
 		cmds = append(cmds, tea.RequestBackgroundColor)
 	}
-	cmds = append(cmds, a.app.InitializeProvider())
+
+	// a session or prompt given on the command line is applied once the
+	// provider has been initialized
+	startup := []tea.Cmd{a.app.InitializeProvider()}
+	if a.app.Session.ID != "" {
+		startup = append(startup, util.CmdHandler(app.SessionSelectedMsg(a.app.Session)))
+	}
+	if a.initialPrompt != "" {
+		startup = append(startup, util.CmdHandler(app.SendMsg{Text: a.initialPrompt}))
+	}
+	cmds = append(cmds, tea.Sequence(startup...))
 	cmds = append(cmds, a.editor.Init())
 	cmds = append(cmds, a.messages.Init())
 	cmds = append(cmds, a.status.Init())
@@ -127,6 +151,14 @@ func isScrollRelatedInput(keyString string) bool {
 }
 
 func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := a.update(msg)
+	// the view reads the tabs, which don't follow App.Session and
+	// App.Messages on their own
+	a.app.SyncTab()
+	return model, cmd
+}
+
+func (a appModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
@@ -142,6 +174,11 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch keyString {
 			// Escape always closes current modal
 			case "esc":
+				if confirmer, ok := a.modal.(layout.Confirmer); ok && confirmer.Confirming() {
+					updatedModal, cmd := a.modal.Update(msg)
+					a.modal = updatedModal.(layout.Modal)
+					return a, cmd
+				}
 				cmd := a.modal.Close()
 				a.modal = nil
 				return a, cmd
@@ -307,6 +344,10 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.fileViewerHit = a.fileViewer.HasFile() &&
 			a.lastMouse.X > a.fileViewerStart &&
 			a.lastMouse.X < a.fileViewerEnd
+	case tea.FocusMsg:
+		a.unfocused = false
+	case tea.BlurMsg:
+		a.unfocused = true
 	case tea.BackgroundColorMsg:
 		styles.Terminal = &styles.TerminalInfo{
 			Background:       msg.Color,
@@ -344,8 +385,44 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, toast.NewErrorToast(msg.Error())
 	case app.SendMsg:
 		a.showCompletionDialog = false
+		if a.app.IsReadOnly() {
+			return a, toast.NewWarningToast("Subagent sessions are read only")
+		}
+		msg.Text = a.app.AttachShellOutput(msg.Text)
+		if a.app.IsBusy() {
+			a.app.QueuePrompt(msg.Text, msg.Attachments)
+			return a, nil
+		}
 		cmd := a.app.SendChatMessage(context.Background(), msg.Text, msg.Attachments)
 		cmds = append(cmds, cmd)
+	case app.RetryMsg:
+		prompt, ok := a.app.RetryPrompt(msg)
+		if !ok {
+			return a, toast.NewInfoToast("Nothing to retry")
+		}
+		cmds = append(cmds, util.CmdHandler(prompt))
+	case app.ShellRequestedMsg:
+		if a.app.IsReadOnly() {
+			return a, toast.NewWarningToast("Subagent sessions are read only")
+		}
+		cmds = append(cmds,
+			toast.NewInfoToast("Running "+msg.Command),
+			a.app.RunShell(context.Background(), msg.Command),
+		)
+	case app.ShellFinishedMsg:
+		a.app.AddShellResult(msg.SessionID, msg.Result)
+		cmds = append(cmds, toast.NewInfoToast(
+			fmt.Sprintf("%s: %s, output will be attached to the next prompt", msg.Result.Command, msg.Result.Status()),
+		))
+	case dialog.QueuedPromptEditMsg:
+		if a.editor.Value() != "" {
+			return a, toast.NewWarningToast("Clear the editor to edit a queued prompt")
+		}
+		if prompt, ok := a.app.RemoveQueuedPrompt(msg.Index); ok {
+			a.editor.SetValue(prompt.Text, prompt.Attachments)
+		}
+	case dialog.HistorySelectedMsg:
+		a.editor.SetValue(msg.Text, nil)
 	case dialog.CompletionDialogCloseMsg:
 		a.showCompletionDialog = false
 	case opencode.EventListResponseEventInstallationUpdated:
@@ -354,58 +431,52 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			toast.WithTitle("New version installed"),
 		)
 	case opencode.EventListResponseEventSessionDeleted:
-		if a.app.Session != nil && msg.Properties.Info.ID == a.app.Session.ID {
-			a.app.Session = &opencode.Session{}
-			a.app.Messages = []opencode.Message{}
+		if a.app.CloseSessionTab(msg.Properties.Info.ID) {
+			cmds = append(cmds, a.tabSwitched())
 		}
-		return a, toast.NewSuccessToast("Session deleted successfully")
+		cmds = append(cmds, toast.NewSuccessToast("Session deleted successfully"))
+		return a, tea.Batch(cmds...)
 	case opencode.EventListResponseEventSessionUpdated:
-		if msg.Properties.Info.ID == a.app.Session.ID {
-			a.app.Session = &msg.Properties.Info
-		}
+		a.app.UpdateSession(msg.Properties.Info)
 	case opencode.EventListResponseEventMessageUpdated:
-		if msg.Properties.Info.Metadata.SessionID == a.app.Session.ID {
-			exists := false
-			optimisticReplaced := false
-
-			// First check if this is replacing an optimistic message
-			if msg.Properties.Info.Role == opencode.MessageRoleUser {
-				// Look for optimistic messages to replace
-				for i, m := range a.app.Messages {
-					if strings.HasPrefix(m.ID, "optimistic-") && m.Role == opencode.MessageRoleUser {
-						// Replace the optimistic message with the real one
-						a.app.Messages[i] = msg.Properties.Info
-						exists = true
-						optimisticReplaced = true
-						break
-					}
+		notification, ok := a.app.MessageNotification(msg.Properties.Info)
+		if ok && a.unfocused {
+			cmds = append(cmds, a.app.Notify(notification))
+		}
+		a.app.RecordSpend(msg.Properties.Info)
+		if a.app.UpdateMessage(msg.Properties.Info) {
+			info := msg.Properties.Info
+			if info.Role == opencode.MessageRoleAssistant && info.Metadata.Time.Completed > 0 {
+				if warning, ok := a.app.BudgetWarning(); ok {
+					cmds = append(cmds, toast.NewWarningToast(warning, toast.WithTitle("Budget")))
 				}
-			}
-
-			// If not replacing optimistic, check for existing message with same ID
-			if !optimisticReplaced {
-				for i, m := range a.app.Messages {
-					if m.ID == msg.Properties.Info.ID {
-						a.app.Messages[i] = msg.Properties.Info
-						exists = true
-						break
-					}
+				if !info.Metadata.Assistant.Summary && a.app.ShouldAutoCompact() {
+					cmds = append(cmds,
+						toast.NewInfoToast("Context is getting full, compacting session"),
+						a.app.CompactSession(context.Background()),
+					)
+				} else {
+					cmds = append(cmds, a.app.SendQueuedPrompt(context.Background()))
 				}
 			}
-
-			if !exists {
-				a.app.Messages = append(a.app.Messages, msg.Properties.Info)
-			}
 		}
+	case opencode.EventListResponseEventSessionIdle:
+		cmds = append(cmds, a.app.SendQueuedPromptIn(context.Background(), msg.Properties.SessionID))
 	case opencode.EventListResponseEventSessionError:
 		switch err := msg.Properties.Error.AsUnion().(type) {
 		case nil:
 		case opencode.ProviderAuthError:
 			slog.Error("Failed to authenticate with provider", "error", err.Data.Message)
-			return a, toast.NewErrorToast("Provider error: " + err.Data.Message)
+			return a, tea.Batch(
+				toast.NewErrorToast("Provider error: "+err.Data.Message),
+				a.notifyError("Provider error: "+err.Data.Message),
+			)
 		case opencode.UnknownError:
 			slog.Error("Server error", "name", err.Name, "message", err.Data.Message)
-			return a, toast.NewErrorToast(err.Data.Message, toast.WithTitle(string(err.Name)))
+			return a, tea.Batch(
+				toast.NewErrorToast(err.Data.Message, toast.WithTitle(string(err.Name))),
+				a.notifyError(err.Data.Message),
+			)
 		}
 	case opencode.EventListResponseEventFileWatcherUpdated:
 		if a.fileViewer.HasFile() {
@@ -454,12 +525,82 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			slog.Error("Failed to list messages", "error", err)
 			return a, toast.NewErrorToast("Failed to open session")
 		}
-		a.app.Session = msg
-		a.app.Messages = messages
-		return a, util.CmdHandler(app.SessionLoadedMsg{})
+		a.app.OpenTab(msg, messages)
+		return a, a.tabSwitched()
+	case app.TaskSessionOpenedMsg:
+		a.app.OpenReadOnlyTab(&msg.Session, msg.Messages)
+		return a, a.tabSwitched()
+	case dialog.ExportConfirmedMsg:
+		transcript := ""
+		if msg.Format == app.ExportHTML {
+			transcript = a.messages.Transcript()
+		}
+		cmds = append(cmds, a.app.ExportSession(msg.Path, msg.Format, transcript))
+	case dialog.ImportConfirmedMsg:
+		cmds = append(cmds, a.app.ImportSession(context.Background(), msg.Path))
+	case app.BudgetExceededMsg:
+		a.modal = dialog.NewBudgetDialog(msg)
+	case dialog.BudgetConfirmedMsg:
+		if msg.Raise {
+			limit := a.app.RaiseBudget(msg.Exceeded)
+			cmds = append(cmds, toast.NewInfoToast(fmt.Sprintf("%s budget raised to $%.2f", msg.Exceeded.Budget, limit)))
+		} else {
+			a.app.OverrideBudget(msg.Exceeded)
+		}
+		cmds = append(cmds, util.CmdHandler(app.SendMsg{Text: msg.Exceeded.Text, Attachments: msg.Exceeded.Attachments}))
+	case dialog.BudgetDeclinedMsg:
+		if a.editor.Value() != "" {
+			return a, toast.NewWarningToast("Prompt not sent, budget reached")
+		}
+		a.editor.SetValue(msg.Text, msg.Attachments)
+	case dialog.RevertConfirmedMsg:
+		cmds = append(cmds, a.app.RevertMessage(context.Background(), msg.MessageID))
+	case app.SessionRevertedMsg:
+		a.app.UpdateSession(msg.Session)
+	case app.ShareChangedMsg:
+		switch {
+		case msg.Err != nil && msg.Shared:
+			cmds = append(cmds, toast.NewErrorToast("Failed to share session"))
+		case msg.Err != nil:
+			cmds = append(cmds, toast.NewErrorToast("Failed to unshare session"))
+		case msg.Shared:
+			a.app.UpdateSession(msg.Session)
+			cmds = append(cmds, tea.SetClipboard(msg.Session.Share.URL))
+			cmds = append(cmds, toast.NewSuccessToast("Share URL copied to clipboard!"))
+		default:
+			a.app.UpdateSession(msg.Session)
+			cmds = append(cmds, toast.NewInfoToast("Session is no longer shared"))
+		}
+	case app.CompactionFinishedMsg:
+		if a.app.Compaction != nil && a.app.Compaction.SessionID == msg.SessionID {
+			a.app.Compaction = nil
+		}
+		if msg.Err != nil {
+			return a, toast.NewErrorToast("Failed to compact session: " + msg.Err.Error())
+		}
+		return a, tea.Batch(
+			toast.NewSuccessToast("Session compacted"),
+			a.app.SendQueuedPrompt(context.Background()),
+		)
+	case app.ReconnectedMsg:
+		cmds = append(cmds, a.app.Resync(context.Background()))
+	case app.SessionResyncedMsg:
+		a.app.UpdateMessages(msg.Session, msg.Messages)
+	case app.ModelFoundMsg:
+		if !msg.Found {
+			return a, toast.NewErrorToast("Unknown model " + msg.Name)
+		}
+		selected := util.CmdHandler(app.ModelSelectedMsg{Provider: msg.Provider, Model: msg.Model})
+		if msg.Retry {
+			return a, tea.Sequence(selected, util.CmdHandler(app.RetryMsg{}))
+		}
+		return a, selected
 	case app.ModelSelectedMsg:
 		a.app.Provider = &msg.Provider
 		a.app.Model = &msg.Model
+		if msg.Override {
+			break
+		}
 		a.app.State.Provider = msg.Provider.ID
 		a.app.State.Model = msg.Model.ID
 		a.app.State.UpdateModelUsage(msg.Provider.ID, msg.Model.ID)
@@ -519,11 +660,18 @@ func (a appModel) View() string {
 
 	var mainLayout string
 	mainWidth := layout.Current.Container.Width - 4
+	tabBar := a.tabBar(mainWidth)
+	if tabBar != "" {
+		a.height -= lipgloss.Height(tabBar)
+	}
 	if a.app.Session.ID == "" {
 		mainLayout = a.home(mainWidth)
 	} else {
 		mainLayout = a.chat(mainWidth)
 	}
+	if tabBar != "" {
+		mainLayout = tabBar + "\n" + mainLayout
+	}
 	mainLayout = styles.NewStyle().
 		Background(t.Background()).
 		Padding(0, 2).
@@ -595,6 +743,30 @@ func (a appModel) openFile(filepath string) (tea.Model, tea.Cmd) {
 	return a, cmd
 }
 
+// tabBar renders the open sessions, or nothing while only one is open.
+func (a appModel) tabBar(width int) string {
+	tabs := a.app.Tabs()
+	if len(tabs) < 2 {
+		return ""
+	}
+	t := theme.CurrentTheme()
+	bar := ""
+	for i, tab := range tabs {
+		style := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundPanel())
+		if i == a.app.ActiveTab() {
+			style = styles.NewStyle().Foreground(t.Text()).Background(t.BackgroundElement()).Bold(true)
+		}
+		title := truncate.StringWithTail(tab.Title(), 24, "…")
+		bar += style.Render(fmt.Sprintf(" %d %s", i+1, title))
+		if tab.IsBusy() {
+			bar += style.Foreground(t.Primary()).Render(" ●")
+		}
+		bar += style.Render(" ")
+	}
+	bar = truncate.StringWithTail(bar, uint(width), "…")
+	return styles.NewStyle().Background(t.Background()).Width(width).Render(bar)
+}
+
 func (a appModel) home(width int) string {
 	t := theme.CurrentTheme()
 	baseStyle := styles.NewStyle().Background(t.Background())
@@ -711,7 +883,10 @@ func (a appModel) home(width int) string {
 func (a appModel) chat(width int) string {
 	editorView := a.editor.View(width)
 	lines := a.editor.Lines()
-	messagesView := a.messages.View(width, a.height-5)
+	if queueView := chat.QueueView(a.app, width); queueView != "" {
+		editorView = queueView + "\n" + editorView
+	}
+	messagesView := a.messages.View(width, a.height-lipgloss.Height(editorView))
 
 	editorWidth := lipgloss.Width(editorView)
 	editorHeight := max(lines, 5)
@@ -746,11 +921,36 @@ func (a appModel) chat(width int) string {
 	return mainLayout
 }
 
+// notifyError tells the user about a session error if they are looking at
+// another window.
+func (a appModel) notifyError(message string) tea.Cmd {
+	if !a.unfocused {
+		return nil
+	}
+	return a.app.Notify(app.Notification{Title: a.app.Session.Title, Body: message})
+}
+
+// tabSwitched reloads the messages view for the newly active tab and sends
+// prompts queued while it was in the background.
+func (a appModel) tabSwitched() tea.Cmd {
+	if a.app.Session.ID == "" {
+		return util.CmdHandler(app.SessionClearedMsg{})
+	}
+	return tea.Batch(
+		util.CmdHandler(app.SessionLoadedMsg{}),
+		a.app.SendQueuedPrompt(context.Background()),
+	)
+}
+
 func (a appModel) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	cmds := []tea.Cmd{
 		util.CmdHandler(commands.CommandExecutedMsg(command)),
 	}
+	if command.IsCustom() {
+		cmds = append(cmds, util.CmdHandler(a.app.CustomCommandPrompt(command, command.Arguments)))
+		return a, tea.Batch(cmds...)
+	}
 	switch command.Name {
 	case commands.AppHelpCommand:
 		helpDialog := dialog.NewHelpDialog(a.app)
@@ -766,6 +966,7 @@ func (a appModel) executeCommand(command commands.Command) (tea.Model, tea.Cmd)
 		}
 
 		value := a.editor.Value()
+		attachments := a.editor.Attachments()
 		updated, cmd := a.editor.Clear()
 		a.editor = updated.(chat.EditorComponent)
 		cmds = append(cmds, cmd)
@@ -796,11 +997,9 @@ func (a appModel) executeCommand(command commands.Command) (tea.Model, tea.Cmd)
 				return nil
 			}
 			os.Remove(tmpfile.Name())
-			// attachments := m.attachments
-			// m.attachments = nil
 			return app.SendMsg{
 				Text:        string(content),
-				Attachments: []app.Attachment{}, // attachments,
+				Attachments: attachments,
 			}
 		})
 		cmds = append(cmds, cmd)
@@ -808,24 +1007,68 @@ func (a appModel) executeCommand(command commands.Command) (tea.Model, tea.Cmd)
 		if a.app.Session.ID == "" {
 			return a, nil
 		}
-		a.app.Session = &opencode.Session{}
-		a.app.Messages = []opencode.Message{}
+		a.app.NewTab()
 		cmds = append(cmds, util.CmdHandler(app.SessionClearedMsg{}))
 	case commands.SessionListCommand:
-		sessionDialog := dialog.NewSessionDialog(a.app)
+		sessionDialog := dialog.NewSessionDialog(a.app, command.Arguments)
 		a.modal = sessionDialog
-	case commands.SessionShareCommand:
+		cmds = append(cmds, sessionDialog.Init())
+	case commands.SessionExportCommand:
 		if a.app.Session.ID == "" {
+			return a, toast.NewInfoToast("Nothing to export yet")
+		}
+		if command.Arguments != "" {
+			// /export [format] [path]
+			format := app.ExportMarkdown
+			arguments := strings.Fields(command.Arguments)
+			if parsed, ok := app.ParseExportFormat(arguments[0]); ok {
+				format = parsed
+				arguments = arguments[1:]
+			}
+			path := strings.Join(arguments, " ")
+			if path == "" {
+				path = a.app.ExportPath(format)
+			}
+			cmds = append(cmds, util.CmdHandler(dialog.ExportConfirmedMsg{Path: path, Format: format}))
+			break
+		}
+		exportDialog := dialog.NewExportDialog(a.app.ExportPath(app.ExportMarkdown))
+		a.modal = exportDialog
+		cmds = append(cmds, exportDialog.Init())
+	case commands.SessionImportCommand:
+		if command.Arguments != "" {
+			cmds = append(cmds, util.CmdHandler(dialog.ImportConfirmedMsg{Path: command.Arguments}))
+			break
+		}
+		importDialog := dialog.NewImportDialog(a.app.Info.Path.Root)
+		a.modal = importDialog
+		cmds = append(cmds, importDialog.Init())
+	case commands.ShellClearCommand:
+		if len(a.app.ShellResults()) == 0 {
+			return a, toast.NewInfoToast("No shell output to discard")
+		}
+		a.app.ClearShellResults()
+	case commands.TabNextCommand:
+		if a.app.SwitchTab(1) {
+			cmds = append(cmds, a.tabSwitched())
+		}
+	case commands.TabPreviousCommand:
+		if a.app.SwitchTab(-1) {
+			cmds = append(cmds, a.tabSwitched())
+		}
+	case commands.TabCloseCommand:
+		if len(a.app.Tabs()) < 2 && a.app.Session.ID == "" {
 			return a, nil
 		}
-		response, err := a.app.Client.Session.Share(context.Background(), a.app.Session.ID)
-		if err != nil {
-			slog.Error("Failed to share session", "error", err)
-			return a, toast.NewErrorToast("Failed to share session")
+		a.app.CloseTab()
+		cmds = append(cmds, a.tabSwitched())
+	case commands.SessionShareCommand:
+		if a.app.Session.ID == "" {
+			return a, nil
 		}
-		shareUrl := response.Share.URL
-		cmds = append(cmds, tea.SetClipboard(shareUrl))
-		cmds = append(cmds, toast.NewSuccessToast("Share URL copied to clipboard!"))
+		shareDialog := dialog.NewShareDialog(a.app)
+		a.modal = shareDialog
+		cmds = append(cmds, shareDialog.Init())
 	case commands.SessionInterruptCommand:
 		if a.app.Session.ID == "" {
 			return a, nil
@@ -836,8 +1079,20 @@ func (a appModel) executeCommand(command commands.Command) (tea.Model, tea.Cmd)
 		if a.app.Session.ID == "" {
 			return a, nil
 		}
-		// TODO: block until compaction is complete
-		a.app.CompactSession(context.Background())
+		if a.app.IsBusy() {
+			return a, toast.NewWarningToast("Can't compact while the agent is working")
+		}
+		cmds = append(cmds, a.app.CompactSession(context.Background()))
+	case commands.SessionQueueCommand:
+		if len(a.app.QueuedPrompts()) == 0 {
+			return a, toast.NewInfoToast("No prompts are queued")
+		}
+		queueDialog := dialog.NewQueueDialog(a.app)
+		a.modal = queueDialog
+	case commands.SessionUsageCommand:
+		usageDialog := dialog.NewUsageDialog(a.app)
+		a.modal = usageDialog
+		cmds = append(cmds, usageDialog.Init())
 	case commands.ToolDetailsCommand:
 		message := "Tool details are now visible"
 		if a.messages.ToolDetailsVisible() {
@@ -846,9 +1101,20 @@ func (a appModel) executeCommand(command commands.Command) (tea.Model, tea.Cmd)
 		cmds = append(cmds, util.CmdHandler(chat.ToggleToolDetailsMsg{}))
 		cmds = append(cmds, toast.NewInfoToast(message))
 	case commands.ModelListCommand:
+		if command.Arguments != "" {
+			cmds = append(cmds, a.app.LookupModel(context.Background(), command.Arguments, false))
+			break
+		}
 		modelDialog := dialog.NewModelDialog(a.app)
 		a.modal = modelDialog
 	case commands.ThemeListCommand:
+		if command.Arguments != "" {
+			if err := theme.SetTheme(command.Arguments); err != nil {
+				return a, toast.NewErrorToast("Unknown theme " + command.Arguments)
+			}
+			cmds = append(cmds, util.CmdHandler(dialog.ThemeSelectedMsg{ThemeName: command.Arguments}))
+			break
+		}
 		themeDialog := dialog.NewThemeDialog()
 		a.modal = themeDialog
 	case commands.FileListCommand:
@@ -888,6 +1154,13 @@ func (a appModel) executeCommand(command commands.Command) (tea.Model, tea.Cmd)
 		updated, cmd := a.editor.Newline()
 		a.editor = updated.(chat.EditorComponent)
 		cmds = append(cmds, cmd)
+	case commands.InputHistoryCommand:
+		if a.app.IsCompacting() || a.app.IsReadOnly() {
+			return a, nil
+		}
+		historyDialog := dialog.NewHistoryDialog(a.app, a.editor.Value())
+		a.modal = historyDialog
+		cmds = append(cmds, historyDialog.Init())
 	case commands.MessagesFirstCommand:
 		updated, cmd := a.messages.First()
 		a.messages = updated.(chat.MessagesComponent)
@@ -953,7 +1226,83 @@ func (a appModel) executeCommand(command commands.Command) (tea.Model, tea.Cmd)
 			cmds = append(cmds, cmd)
 		}
 	case commands.MessagesRevertCommand:
+		if a.app.Session.ID == "" {
+			return a, nil
+		}
+		if a.app.IsBusy() {
+			return a, toast.NewWarningToast("Can't revert while the agent is working")
+		}
+		message, ok := a.messages.SelectedMessage()
+		if !ok {
+			// without a selection, undo the last prompt
+			visible := a.app.VisibleMessages()
+			for i := len(visible) - 1; i >= 0 && !ok; i-- {
+				if visible[i].Role == opencode.MessageRoleUser {
+					message, ok = visible[i], true
+				}
+			}
+		}
+		if !ok {
+			return a, toast.NewInfoToast("Nothing to revert")
+		}
+		reverted := 0
+		for _, m := range a.app.VisibleMessages() {
+			if m.ID >= message.ID {
+				reverted++
+			}
+		}
+		a.modal = dialog.NewRevertDialog(message.ID, reverted, a.app.ChangedFiles(message.ID))
+	case commands.MessagesRedoCommand:
+		if _, ok := a.app.RevertPoint(); !ok {
+			return a, toast.NewInfoToast("Nothing to redo")
+		}
+		cmds = append(cmds, a.app.UndoRevert(context.Background()))
+	case commands.MessagesForkCommand:
+		if a.app.Session.ID == "" {
+			return a, nil
+		}
+		message, ok := a.messages.SelectedMessage()
+		if !ok {
+			return a, toast.NewInfoToast("Select a message to fork from")
+		}
+		cmds = append(cmds, a.app.ForkSession(context.Background(), message.ID))
+	case commands.MessagesOpenTaskCommand:
+		message, ok := a.messages.SelectedMessage()
+		if !ok {
+			return a, toast.NewInfoToast("Select a task to open")
+		}
+		sessionID := app.TaskSessionID(message, a.messages.SelectedToolCall())
+		if sessionID == "" {
+			sessionID = app.TaskSessionID(message, "")
+		}
+		if sessionID == "" {
+			return a, toast.NewInfoToast("No task session to open")
+		}
+		cmds = append(cmds, a.app.OpenTaskSession(context.Background(), sessionID))
+	case commands.SessionRetryCommand:
+		if a.app.IsBusy() {
+			return a, toast.NewWarningToast("Wait for the agent to finish before retrying")
+		}
+		if command.Arguments != "" {
+			return a, a.app.LookupModel(context.Background(), command.Arguments, true)
+		}
+		cmds = append(cmds, util.CmdHandler(app.RetryMsg{}))
+	case commands.SessionRetryModelCommand:
+		if a.app.IsBusy() {
+			return a, toast.NewWarningToast("Wait for the agent to finish before retrying")
+		}
+		a.modal = dialog.NewModelDialog(a.app, dialog.WithRetry())
+	case commands.SessionContinueCommand:
+		if a.app.IsBusy() {
+			return a, toast.NewWarningToast("Wait for the agent to finish before continuing")
+		}
+		err, _ := a.app.FailedTurn()
+		if _, ok := err.AsUnion().(opencode.MessageMetadataErrorMessageOutputLengthError); !ok {
+			return a, toast.NewInfoToast("The last reply was not cut off")
+		}
+		cmds = append(cmds, util.CmdHandler(app.RetryMsg{Continue: true}))
 	case commands.AppExitCommand:
+		a.editor.SaveDraft()
 		return a, tea.Quit
 	}
 	return a, tea.Batch(cmds...)
@@ -968,7 +1317,7 @@ func (a appModel) updateCompletions(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a.completions.Update(msg)
 }
 
-func NewModel(app *app.App) tea.Model {
+func NewModel(app *app.App, opts ...Option) tea.Model {
 	completionManager := completions.NewCompletionManager(app)
 	initialProvider := completionManager.DefaultProvider()
 
@@ -997,6 +1346,9 @@ func NewModel(app *app.App) tea.Model {
 		fileViewer:           fileviewer.New(app),
 		messagesRight:        app.State.MessagesRight,
 	}
+	for _, opt := range opts {
+		opt(model)
+	}
 
 	return model
 }
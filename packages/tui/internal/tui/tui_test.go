@@ -0,0 +1,772 @@
+package tui
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-sdk-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/commands"
+	"github.com/sst/opencode/internal/components/chat"
+	"github.com/sst/opencode/internal/components/dialog"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestHome(t *testing.T) {
+	h := newHarness(t, testserver.New(t))
+	h.assertGolden("home")
+
+	// custom commands come after the built-in ones, past the limit
+	h.app.Commands.AddCustom([]commands.Command{
+		{Name: "custom_add", Trigger: "add", Description: "custom command", Template: "Add $ARGUMENTS"},
+		{Name: "custom_build", Trigger: "build", Description: "custom command", Template: "Build it"},
+	})
+	h.assertGolden("home")
+}
+
+func TestSendMessage(t *testing.T) {
+	server := testserver.New(t)
+	server.OnChat = testserver.Reply("Hello from the test model.")
+	h := newHarness(t, server)
+
+	h.typeText("hello")
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+
+	if h.app.Session.ID == "" {
+		t.Fatal("no session was created")
+	}
+	if got := len(server.Messages(h.app.Session.ID)); got != 2 {
+		t.Fatalf("server has %d messages, want 2", got)
+	}
+	h.assertGolden("send_message")
+}
+
+func TestPromptHistory(t *testing.T) {
+	server := testserver.New(t)
+	server.OnChat = testserver.Reply("Done.")
+	h := newHarness(t, server)
+	editor := func() string { return h.model.(appModel).editor.Value() }
+
+	for _, prompt := range []string{"first prompt", "second prompt", "first prompt"} {
+		h.typeText(prompt)
+		h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	}
+	if got := h.app.History(); !reflect.DeepEqual(got, []string{"second prompt", "first prompt"}) {
+		t.Fatalf("history is %q, want it deduped with the latest last", got)
+	}
+
+	h.typeText("draft")
+	for _, step := range []struct {
+		key  rune
+		want string
+	}{
+		{tea.KeyUp, "first prompt"},
+		{tea.KeyUp, "second prompt"},
+		{tea.KeyUp, "second prompt"},
+		{tea.KeyDown, "first prompt"},
+		{tea.KeyDown, "draft"},
+	} {
+		h.send(tea.KeyPressMsg{Code: step.key})
+		if got := editor(); got != step.want {
+			t.Fatalf("editor shows %q, want %q", got, step.want)
+		}
+	}
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.InputClearCommand]))
+	h.send(tea.KeyPressMsg{Code: 'r', Mod: tea.ModCtrl})
+	h.typeText("sec")
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if got := editor(); got != "second prompt" {
+		t.Fatalf("editor shows %q after searching the history, want second prompt", got)
+	}
+
+	// the history outlives the app
+	reopened, err := app.New(context.Background(), "test", testserver.AppIn(filepath.Dir(h.app.StatePath)), server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.History(); !reflect.DeepEqual(got, h.app.History()) {
+		t.Errorf("history is %q after reopening, want %q", got, h.app.History())
+	}
+}
+
+func TestDraft(t *testing.T) {
+	server := testserver.New(t)
+	first := testserver.NewSession("ses_first", "First session")
+	second := testserver.NewSession("ses_second", "Second session")
+	server.AddSession(first)
+	server.AddSession(second)
+	h := newHarness(t, server)
+	editor := func() string { return h.model.(appModel).editor.Value() }
+
+	h.send(app.SessionSelectedMsg(&first))
+	h.typeText("half written")
+	h.send(app.SessionSelectedMsg(&second))
+	if got := editor(); got != "" {
+		t.Fatalf("editor shows %q in another session, want it empty", got)
+	}
+	h.send(app.SessionSelectedMsg(&first))
+	if got := editor(); got != "half written" {
+		t.Fatalf("editor shows %q back in the session, want the draft", got)
+	}
+
+	// a new editor, as on the next launch, picks the draft up too
+	h.model.(appModel).editor.SaveDraft()
+	if got := chat.NewEditorComponent(h.app).Value(); got != "half written" {
+		t.Errorf("new editor shows %q, want the draft", got)
+	}
+
+	// sending the prompt forgets the draft
+	h.send(app.SessionSelectedMsg(&second))
+	h.send(app.SessionSelectedMsg(&first))
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if _, ok := h.app.LoadDraft(first.ID); ok {
+		t.Error("draft is kept after the prompt was sent")
+	}
+}
+
+func TestSendAttachment(t *testing.T) {
+	server := testserver.New(t)
+	h := newHarness(t, server)
+
+	h.send(app.SendMsg{
+		Text: "what is this?",
+		Attachments: []app.Attachment{{
+			FilePath: "screenshot.png",
+			FileName: "screenshot.png",
+			MimeType: "image/png",
+			Content:  []byte("not really a png"),
+		}},
+	})
+
+	messages := server.Messages(h.app.Session.ID)
+	if len(messages) != 1 {
+		t.Fatalf("server has %d messages, want 1", len(messages))
+	}
+	file, ok := messages[0].Parts[1].AsUnion().(opencode.FilePart)
+	if !ok {
+		t.Fatalf("second part is %T, want a file part", messages[0].Parts[1].AsUnion())
+	}
+	if want := "data:image/png;base64,bm90IHJlYWxseSBhIHBuZw=="; file.URL != want {
+		t.Errorf("file part url is %q, want %q", file.URL, want)
+	}
+	h.assertGolden("send_attachment")
+}
+
+func TestRevert(t *testing.T) {
+	server := testserver.New(t)
+	h, _ := newSessionHarness(t, server, "First prompt", "First reply", "Second prompt", "Second reply")
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.MessagesRevertCommand]))
+	h.assertGolden("revert_dialog")
+
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if revert, ok := h.app.RevertPoint(); !ok || revert.MessageID != "msg_3" {
+		t.Fatalf("session reverted to %+v, want msg_3", revert)
+	}
+	h.assertGolden("reverted")
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.MessagesRedoCommand]))
+	if _, ok := h.app.RevertPoint(); ok {
+		t.Fatal("session is still reverted after redo")
+	}
+	if frame := h.frame(); !strings.Contains(frame, "Second reply") {
+		t.Errorf("reverted messages were not restored:\n%s", frame)
+	}
+}
+
+func TestCompactSession(t *testing.T) {
+	server := testserver.New(t)
+	release := make(chan struct{})
+	server.Handle("POST", "/session/ses_existing/summarize", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("true"))
+	})
+	h, session := newSessionHarness(t, server, "First prompt", "First reply")
+
+	// the summarize request blocks until released, so its command is only
+	// run afterwards
+	compact := h.update(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionCompactCommand]))
+	if !h.app.IsCompacting() {
+		t.Fatal("session is not compacting")
+	}
+	h.typeText("ignored")
+	h.send(app.SendMsg{Text: "blocked"})
+	if got := len(server.Messages(session.ID)); got != 2 {
+		t.Fatalf("server has %d messages while compacting, want 2", got)
+	}
+	// keep the elapsed time in the status bar stable
+	h.app.Compaction.Started = time.Now()
+	h.assertGolden("compacting")
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionCompactCommand]))
+	if frame := h.frame(); !strings.Contains(frame, "Compaction is already running") {
+		t.Errorf("compacting again is not refused:\n%s", frame)
+	}
+
+	close(release)
+	h.run(compact)
+	h.send(app.CompactionFinishedMsg{SessionID: session.ID})
+	if h.app.IsCompacting() {
+		t.Fatal("session is still compacting")
+	}
+}
+
+func TestQueuePrompt(t *testing.T) {
+	server := testserver.New(t)
+	server.OnChat = testserver.Reply("Second reply")
+	session := existingSession(server, "First prompt")
+	server.AddMessage(testserver.AssistantMessage("msg_2", session.ID, "Working on it", false))
+	h := newHarness(t, server)
+	h.send(app.SessionSelectedMsg(&session))
+
+	h.send(app.SendMsg{Text: "Second prompt"})
+	h.send(app.SendMsg{Text: "Third prompt"})
+	if got := len(h.app.QueuedPrompts()); got != 2 {
+		t.Fatalf("%d prompts queued, want 2", got)
+	}
+	h.assertGolden("queued")
+
+	// reorder and cancel from the queue dialog
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionQueueCommand]))
+	h.send(tea.KeyPressMsg{Code: tea.KeyDown, Mod: tea.ModShift})
+	if got := h.app.QueuedPrompts()[0].Text; got != "Third prompt" {
+		t.Fatalf("first queued prompt is %q after moving, want Third prompt", got)
+	}
+	h.send(tea.KeyPressMsg{Code: 'x', Text: "x"})
+	h.send(tea.KeyPressMsg{Code: tea.KeyEscape})
+
+	server.AddMessage(testserver.AssistantMessage("msg_2", session.ID, "First reply", true))
+	h.drain()
+	if len(h.app.QueuedPrompts()) != 0 {
+		t.Fatal("queued prompt was not sent")
+	}
+	messages := server.Messages(session.ID)
+	if len(messages) != 4 {
+		t.Fatalf("server has %d messages, want 4", len(messages))
+	}
+	if got := messages[2].Parts[0].AsUnion().(opencode.TextPart).Text; got != "Third prompt" {
+		t.Errorf("sent %q, want Third prompt", got)
+	}
+}
+
+func TestTabs(t *testing.T) {
+	server := testserver.New(t)
+	first := testserver.NewSession("ses_first", "First session")
+	second := testserver.NewSession("ses_second", "Second session")
+	server.AddSession(first)
+	server.AddSession(second)
+	server.AddMessage(testserver.UserMessage("msg_1", first.ID, "First prompt"))
+	server.AddMessage(testserver.AssistantMessage("msg_2", first.ID, "Working on it", false))
+	h := newHarness(t, server)
+	h.send(app.SessionSelectedMsg(&first))
+	h.send(app.SendMsg{Text: "Queued prompt"})
+	h.send(app.SessionSelectedMsg(&second))
+
+	if got := len(h.app.Tabs()); got != 2 {
+		t.Fatalf("%d tabs open, want 2", got)
+	}
+	if h.app.Session.ID != second.ID {
+		t.Fatalf("active session is %q, want %q", h.app.Session.ID, second.ID)
+	}
+	frame := h.frame()
+	if !strings.Contains(frame, "1 First session ●") || !strings.Contains(frame, "2 Second session") {
+		t.Errorf("tab bar is missing a session or its busy indicator:\n%s", frame)
+	}
+
+	// events for the background tab are kept rather than dropped
+	server.AddMessage(testserver.AssistantMessage("msg_2", first.ID, "First reply", true))
+	server.Emit("session.idle", map[string]any{"sessionID": first.ID})
+	h.drain()
+	if strings.Contains(h.frame(), "First reply") {
+		t.Fatal("message of the background tab is shown in the active one")
+	}
+	// so is its queue
+	messages := server.Messages(first.ID)
+	if got := messages[len(messages)-1].Parts[0].AsUnion().(opencode.TextPart).Text; got != "Queued prompt" {
+		t.Errorf("last prompt of the background tab is %q, want Queued prompt", got)
+	}
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.TabNextCommand]))
+	if h.app.Session.ID != first.ID {
+		t.Fatalf("active session is %q after switching, want %q", h.app.Session.ID, first.ID)
+	}
+	if frame := h.frame(); !strings.Contains(frame, "First reply") {
+		t.Errorf("background update was lost:\n%s", frame)
+	}
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.TabCloseCommand]))
+	if got := len(h.app.Tabs()); got != 1 || h.app.Session.ID != second.ID {
+		t.Fatalf("after closing, %d tabs open with %q active, want 1 with %q", got, h.app.Session.ID, second.ID)
+	}
+}
+
+func TestForkSession(t *testing.T) {
+	server := testserver.New(t)
+	h, session := newSessionHarness(t, server, "First prompt", "First reply", "Second prompt", "Second reply")
+
+	// select the first reply
+	for range 3 {
+		h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.MessagesPreviousCommand]))
+	}
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.MessagesForkCommand]))
+
+	fork, ok := app.ForkedFrom(*h.app.Session)
+	if h.app.Session.ID == session.ID || !ok || fork.SessionID != session.ID || h.app.Session.ParentID != "" {
+		t.Fatalf("active session is %q forked from %+v, want a fork of %q", h.app.Session.ID, fork, session.ID)
+	}
+	if got := len(h.app.Messages); got != 2 {
+		t.Fatalf("fork has %d messages, want 2", got)
+	}
+	if got := len(server.Messages(session.ID)); got != 4 {
+		t.Fatalf("original session has %d messages after forking, want 4", got)
+	}
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionListCommand]))
+	if frame := h.frame(); !strings.Contains(frame, "└ Fork of Existing session") {
+		t.Errorf("session list does not show the fork under its parent:\n%s", frame)
+	}
+}
+
+func TestOpenTaskSession(t *testing.T) {
+	server := testserver.New(t)
+	session := existingSession(server, "Look around")
+	child := testserver.NewChildSession("ses_child", session.ID, "Child session")
+	server.AddSession(child)
+	server.AddMessage(testserver.TaskMessage("msg_2", session.ID, child.ID, "Explore the repo"))
+	server.AddMessage(testserver.UserMessage("msg_1", child.ID, "Explore the repo"))
+	server.AddMessage(testserver.AssistantMessage("msg_2", child.ID, "It is a terminal UI.", true))
+	h := newHarness(t, server)
+	h.send(app.SessionSelectedMsg(&session))
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.MessagesPreviousCommand]))
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.MessagesOpenTaskCommand]))
+	if h.app.Session.ID != child.ID || !h.app.IsReadOnly() {
+		t.Fatalf("active session is %q, read only %v, want %q read only", h.app.Session.ID, h.app.IsReadOnly(), child.ID)
+	}
+	if frame := h.frame(); !strings.Contains(frame, "It is a terminal UI.") {
+		t.Errorf("task session is not shown:\n%s", frame)
+	}
+
+	h.typeText("ignored")
+	h.send(app.SendMsg{Text: "ignored"})
+	if got := len(server.Messages(child.ID)); got != 2 {
+		t.Fatalf("task session has %d messages, want 2", got)
+	}
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.TabCloseCommand]))
+	if h.app.Session.ID != session.ID || h.app.IsReadOnly() {
+		t.Fatalf("active session is %q after closing the task, want %q", h.app.Session.ID, session.ID)
+	}
+
+	// picked from the session list, the task session is read only too
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionListCommand]))
+	h.typeText("Child")
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if h.app.Session.ID != child.ID || !h.app.IsReadOnly() {
+		t.Fatalf("active session is %q, read only %v, want %q read only", h.app.Session.ID, h.app.IsReadOnly(), child.ID)
+	}
+}
+
+func TestSessionDialog(t *testing.T) {
+	server := testserver.New(t)
+	for _, session := range []opencode.Session{
+		testserver.NewSession("ses_1", "Fix the parser"),
+		testserver.NewSession("ses_2", "Write release notes"),
+		testserver.NewSession("ses_3", "Parser benchmarks"),
+	} {
+		server.AddSession(session)
+	}
+	server.AddMessage(testserver.UserMessage("msg_1", "ses_2", "Draft the notes"))
+	server.AddMessage(testserver.AssistantMessage("msg_2", "ses_2", "Here they are.", true))
+	h := newHarness(t, server)
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionListCommand]))
+	h.typeText("notes")
+	h.drain()
+	frame := h.frame()
+	if !strings.Contains(frame, "Write release notes") || strings.Contains(frame, "Fix the parser") {
+		t.Fatalf("search did not narrow the list to the match:\n%s", frame)
+	}
+	if !strings.Contains(frame, "2 messages, $0.01, test-model") {
+		t.Errorf("preview is missing the session stats:\n%s", frame)
+	}
+
+	h.send(tea.KeyPressMsg{Code: 'r', Mod: tea.ModCtrl})
+	for range len("notes") {
+		h.dispatch(tea.KeyPressMsg{Code: tea.KeyBackspace})
+	}
+	h.typeText("summary")
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	sessions, err := h.app.ListSessions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, session := range sessions {
+		if session.ID == "ses_2" && session.Title != "Write release summary" {
+			t.Errorf("session is titled %q after renaming, want Write release summary", session.Title)
+		}
+	}
+
+	h.send(tea.KeyPressMsg{Code: 'c', Mod: tea.ModCtrl})
+	h.send(tea.KeyPressMsg{Code: 'd', Mod: tea.ModCtrl})
+	h.send(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if !strings.Contains(h.frame(), "Switch Session") {
+		t.Fatal("esc closed the dialog instead of cancelling the deletion")
+	}
+	h.send(tea.KeyPressMsg{Code: 'd', Mod: tea.ModCtrl})
+	if sessions, err := h.app.ListSessions(context.Background()); err != nil || len(sessions) != 3 {
+		t.Errorf("deleted a session whose deletion was cancelled: %v", err)
+	}
+	h.send(tea.KeyPressMsg{Code: tea.KeyEscape})
+	h.send(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if strings.Contains(h.frame(), "Switch Session") {
+		t.Error("esc did not close the dialog")
+	}
+
+	// ctrl+c clears the search, then closes the dialog
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionListCommand]))
+	h.typeText("parser")
+	h.send(tea.KeyPressMsg{Code: 'c', Mod: tea.ModCtrl})
+	if frame := h.frame(); !strings.Contains(frame, "Switch Session") || !strings.Contains(frame, "Write release summary") {
+		t.Fatalf("ctrl+c did not clear the search:\n%s", frame)
+	}
+	h.send(tea.KeyPressMsg{Code: 'c', Mod: tea.ModCtrl})
+	if strings.Contains(h.frame(), "Switch Session") {
+		t.Error("ctrl+c with an empty search did not close the dialog")
+	}
+}
+
+func TestExportSession(t *testing.T) {
+	server := testserver.New(t)
+	h, _ := newSessionHarness(t, server, "What is in this repo?", "A terminal UI.")
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionExportCommand]))
+	if frame := h.frame(); !strings.Contains(frame, filepath.Join(testserver.Root, "existing-session.md")) {
+		t.Errorf("export path does not default to the project root:\n%s", frame)
+	}
+	h.send(tea.KeyPressMsg{Code: tea.KeyEscape})
+
+	dir := t.TempDir()
+	for format, want := range map[app.ExportFormat]string{
+		app.ExportMarkdown: "## Assistant (test-model)\n\nA terminal UI.",
+		app.ExportJSON:     `"id": "msg_2"`,
+		app.ExportHTML:     "<span style=",
+	} {
+		path := filepath.Join(dir, "session"+format.Extension())
+		h.send(dialog.ExportConfirmedMsg{Path: path, Format: format})
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s export was not written: %v", format, err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s export does not contain %q:\n%s", format, want, data)
+		}
+	}
+}
+
+func TestImportSession(t *testing.T) {
+	server := testserver.New(t)
+	h, session := newSessionHarness(t, server, "What is in this repo?", "A terminal UI.")
+
+	dir := t.TempDir()
+	for format, title := range map[app.ExportFormat]string{
+		app.ExportMarkdown: "Existing session",
+		app.ExportJSON:     "Existing session",
+	} {
+		path := filepath.Join(dir, "session"+format.Extension())
+		h.send(dialog.ExportConfirmedMsg{Path: path, Format: format})
+		h.send(dialog.ImportConfirmedMsg{Path: path})
+
+		if h.app.Session.ID == session.ID || h.app.Session.Title != title {
+			t.Fatalf("%s import did not open a new session titled %q: %+v", format, title, h.app.Session)
+		}
+		messages := server.Messages(h.app.Session.ID)
+		if len(messages) != 2 || messages[0].Role != opencode.MessageRoleUser || messages[1].Role != opencode.MessageRoleAssistant {
+			t.Fatalf("%s import did not recreate the turns: %+v", format, messages)
+		}
+		if text := messages[1].Parts[0].Text; text != "A terminal UI." {
+			t.Errorf("%s import changed the reply to %q", format, text)
+		}
+		if model := messages[1].Metadata.Assistant.ModelID; model != "test-model" {
+			t.Errorf("%s import lost the model, got %q", format, model)
+		}
+		h.send(app.SessionSelectedMsg(&session))
+	}
+}
+
+func TestCommandArguments(t *testing.T) {
+	server := testserver.New(t)
+	h, session := newSessionHarness(t, server, "What is in this repo?", "A terminal UI.")
+
+	// typed through the completion dialog, which stays open for arguments
+	path := filepath.Join(t.TempDir(), "out.json")
+	h.typeText("/export json " + path)
+	h.drain()
+	if frame := h.frame(); !strings.Contains(frame, "/export json "+path) {
+		t.Errorf("completion does not offer the typed arguments:\n%s", frame)
+	}
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), `"id": "msg_2"`) {
+		t.Fatalf("/export json did not export the session: %v", err)
+	}
+
+	// submitted with the completion dialog closed
+	h.model.(appModel).editor.SetValue("/themes tokyonight", nil)
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if got := h.app.State.Theme; got != "tokyonight" {
+		t.Errorf("theme is %q, want tokyonight", got)
+	}
+	if got := len(server.Messages(session.ID)); got != 2 {
+		t.Errorf("command was sent as a prompt, server has %d messages", got)
+	}
+
+	h.model.(appModel).editor.SetValue("/models test/missing-model", nil)
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if frame := h.frame(); !strings.Contains(frame, "Unknown model test/missing-model") {
+		t.Errorf("unknown model was not reported:\n%s", frame)
+	}
+}
+
+func TestShellEscape(t *testing.T) {
+	server := testserver.New(t)
+	h, session := newSessionHarness(t, server)
+	h.app.Info.Path.Cwd = t.TempDir()
+
+	screenshot := app.Attachment{
+		FilePath: "screenshot.png",
+		FileName: "screenshot.png",
+		MimeType: "image/png",
+		Content:  []byte("not really a png"),
+	}
+	h.model.(appModel).editor.SetValue("!echo shell says hi", []app.Attachment{screenshot})
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if got := h.model.(appModel).editor.Attachments(); len(got) != 1 {
+		t.Fatalf("editor has %d attachments after running the command, want them kept for the prompt", len(got))
+	}
+	results := h.app.ShellResults()
+	if len(results) != 1 || results[0].Output != "shell says hi\n" || results[0].ExitCode != 0 {
+		t.Fatalf("command output was not captured: %+v", results)
+	}
+	if frame := h.frame(); !strings.Contains(frame, "$ echo shell says hi") {
+		t.Errorf("command output is not shown:\n%s", frame)
+	}
+
+	h.typeText("why?")
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	messages := server.Messages(session.ID)
+	if len(messages) != 1 || !strings.Contains(messages[0].Parts[0].Text, "shell says hi") {
+		t.Fatalf("command output was not attached to the prompt: %+v", messages)
+	}
+	if len(messages[0].Parts) != 2 {
+		t.Errorf("prompt has %d parts, want the text and the attachment", len(messages[0].Parts))
+	}
+	if len(h.app.ShellResults()) != 0 {
+		t.Error("command output is still waiting after being attached")
+	}
+}
+
+func TestCommandAttachments(t *testing.T) {
+	server := testserver.New(t)
+	h, session := newSessionHarness(t, server)
+	h.app.Commands.AddCustom([]commands.Command{{Name: "custom_review", Trigger: "review", Template: "Review $ARGUMENTS"}})
+	screenshot := app.Attachment{
+		FilePath: "screenshot.png",
+		FileName: "screenshot.png",
+		MimeType: "image/png",
+		Content:  []byte("not really a png"),
+	}
+
+	// a built-in command leaves the attachments for the next prompt
+	h.model.(appModel).editor.SetValue("/themes tokyonight", []app.Attachment{screenshot})
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if got := h.model.(appModel).editor.Attachments(); len(got) != 1 {
+		t.Fatalf("editor has %d attachments after /themes, want 1", len(got))
+	}
+
+	// a custom command sends them with its prompt
+	h.model.(appModel).editor.SetValue("/review the layout", []app.Attachment{screenshot})
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	messages := server.Messages(session.ID)
+	if len(messages) != 1 || messages[0].Parts[0].Text != "Review the layout" || len(messages[0].Parts) != 2 {
+		t.Fatalf("custom command did not send its prompt with the attachment: %+v", messages)
+	}
+	if got := h.model.(appModel).editor.Attachments(); len(got) != 0 {
+		t.Errorf("editor still has %d attachments after sending them", len(got))
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	server := testserver.New(t)
+	h, session := newSessionHarness(t, server)
+	h.app.Info.Path.Cwd = t.TempDir()
+	out := filepath.Join(t.TempDir(), "notifications")
+	h.app.Notifications.Command = `echo "$OPENCODE_NOTIFY_TITLE|$OPENCODE_NOTIFY_BODY" >> ` + out
+
+	server.AddMessage(testserver.AssistantMessage("msg_1", session.ID, "Done while watching.", true))
+	h.drain()
+	if _, err := os.Stat(out); err == nil {
+		t.Fatal("notified while the terminal was focused")
+	}
+
+	h.send(tea.BlurMsg{})
+	server.AddMessage(testserver.AssistantMessage("msg_2", session.ID, "Working.", false))
+	server.AddMessage(testserver.AssistantMessage("msg_2", session.ID, "Done.", true))
+	server.AddMessage(testserver.AssistantMessage("msg_2", session.ID, "Done.", true))
+	h.drain()
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("no notification was sent: %v", err)
+	}
+	if got, want := string(data), "opencode: Existing session|Response finished\n"; got != want {
+		t.Errorf("notifications = %q, want %q", got, want)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	server := testserver.New(t)
+	server.OnChat = testserver.Reply("Done.")
+	server.Configure(`{"keybinds": {"leader": "ctrl+x"}, "budget": {"session": {"warn": 0.01, "limit": 0.02}}}`)
+	h, session := newSessionHarness(t, server)
+
+	h.send(app.SendMsg{Text: "First prompt"})
+	if frame := h.frame(); !strings.Contains(frame, "Session spend is $0.01") {
+		t.Errorf("no warning at the soft limit:\n%s", frame)
+	}
+	h.send(app.SendMsg{Text: "Second prompt"})
+	h.send(app.SendMsg{Text: "Third prompt"})
+	if got := len(server.Messages(session.ID)); got != 4 {
+		t.Fatalf("server has %d messages, want 4", got)
+	}
+	if frame := h.frame(); !strings.Contains(frame, "Budget reached") {
+		t.Fatalf("prompt past the limit was not held back:\n%s", frame)
+	}
+
+	// declining gives the prompt back
+	h.send(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if got := h.model.(appModel).editor.Value(); got != "Third prompt" {
+		t.Fatalf("editor holds %q after declining, want the prompt", got)
+	}
+
+	h.send(tea.KeyPressMsg{Code: tea.KeyEnter})
+	h.send(tea.KeyPressMsg{Code: 'r', Text: "r"})
+	if got := len(server.Messages(session.ID)); got != 6 {
+		t.Fatalf("server has %d messages after raising the limit, want 6", got)
+	}
+	if got := h.app.Budgets.Session.Limit; got != 0.04 {
+		t.Errorf("limit raised to %v, want 0.04", got)
+	}
+	if got := h.app.DailySpend(); got < 0.029 || got > 0.031 {
+		t.Errorf("daily spend is %v, want 0.03", got)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	server := testserver.New(t)
+	other := testserver.NewSession("ses_other", "Other session")
+	server.AddSession(other)
+	server.AddMessage(testserver.AssistantMessage("msg_5", other.ID, "Elsewhere", true))
+	h, _ := newSessionHarness(t, server, "First prompt", "First reply", "Second prompt", "Second reply")
+	h.send(tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionUsageCommand]))
+	frame := h.frame()
+	for _, want := range []string{"Session: $0.02 over 2 turn(s)", "Context ", "test-model"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("session usage does not show %q:\n%s", want, frame)
+		}
+	}
+
+	h.send(tea.KeyPressMsg{Code: tea.KeyTab})
+	if frame := h.frame(); !strings.Contains(frame, "2 session(s): $0.03 over 3 turn(s)") {
+		t.Errorf("project usage is not shown:\n%s", frame)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	server := testserver.New(t)
+	server.OnChat = testserver.Reply("Done.")
+	session := existingSession(server, "Write a long essay")
+	server.AddMessage(testserver.FailedMessage("msg_2", session.ID, "MessageOutputLengthError", ""))
+	h := newHarness(t, server)
+	h.send(app.SessionSelectedMsg(&session))
+
+	frame := h.frame()
+	for _, want := range []string{"Message output length exceeded", "continue cut off reply", "retry last prompt"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("error block does not show %q:\n%s", want, frame)
+		}
+	}
+	lastPrompt := func() string {
+		messages := server.Messages(session.ID)
+		for i := len(messages) - 1; i >= 0; i-- {
+			if messages[i].Role == opencode.MessageRoleUser {
+				return messages[i].Parts[0].AsUnion().(opencode.TextPart).Text
+			}
+		}
+		return ""
+	}
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionRetryCommand]))
+	if got := lastPrompt(); got != "Write a long essay" || len(server.Messages(session.ID)) != 4 {
+		t.Fatalf("retrying sent %q, want the last prompt", got)
+	}
+
+	server.AddMessage(testserver.FailedMessage("msg_5", session.ID, "MessageOutputLengthError", ""))
+	h.drain()
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionContinueCommand]))
+	if got := lastPrompt(); !strings.Contains(got, "Continue exactly where it stopped") {
+		t.Fatalf("continuing sent %q", got)
+	}
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionContinueCommand]))
+	if got := len(server.Messages(session.ID)); got != 7 {
+		t.Errorf("server has %d messages, want 7: a reply that was not cut off was continued", got)
+	}
+}
+
+func TestOpenSession(t *testing.T) {
+	server := testserver.New(t)
+	h, _ := newSessionHarness(t, server, "What is in this repo?", "A terminal UI.")
+	h.assertGolden("open_session")
+}
+
+func TestShare(t *testing.T) {
+	server := testserver.New(t)
+	h, _ := newSessionHarness(t, server)
+	h.send(tea.WindowSizeMsg{Width: 100, Height: 50})
+
+	h.send(commands.ExecuteCommandMsg(h.app.Commands[commands.SessionShareCommand]))
+	h.drain()
+	url := "https://opencode.ai/s/ses_existing"
+	if h.app.Session.Share.URL != url {
+		t.Fatalf("session was not shared: %q", h.app.Session.Share.URL)
+	}
+	frame := h.frame()
+	for _, want := range []string{"Share session", url, "▀", "u unshare"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("share dialog does not show %q:\n%s", want, frame)
+		}
+	}
+
+	h.send(tea.KeyPressMsg{Code: 'u', Text: "u"})
+	h.drain()
+	if h.app.Session.Share.URL != "" {
+		t.Fatalf("session is still shared: %q", h.app.Session.Share.URL)
+	}
+	if frame := h.frame(); !strings.Contains(frame, "This session is not shared") {
+		t.Errorf("share dialog does not show the session is unshared:\n%s", frame)
+	}
+
+	h.send(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if frame := h.frame(); !strings.Contains(frame, "/share to create a shareable link") {
+		t.Errorf("header does not show the session is unshared:\n%s", frame)
+	}
+}
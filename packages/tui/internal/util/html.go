@@ -0,0 +1,244 @@
+package util
+
+import (
+	"fmt"
+	"html"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// ansi16 are the colors used for the 16 basic terminal colors, indexed by
+// their SGR offset, bright variants last.
+var ansi16 = [16]string{
+	"#000000", "#800000", "#008000", "#808000", "#000080", "#800080", "#008080", "#c0c0c0",
+	"#808080", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// CSSColor formats a color for CSS, or returns "inherit" when it is unset
+// or fully transparent, as the system theme's background is.
+func CSSColor(c color.Color) string {
+	if c == nil {
+		return "inherit"
+	}
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return "inherit"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// htmlStyle is the SGR state of a run of text.
+type htmlStyle struct {
+	fg, bg                            string
+	bold, faint, italic, underline    bool
+	strikethrough, reverse, invisible bool
+}
+
+func (s htmlStyle) css() string {
+	fg, bg := s.fg, s.bg
+	if s.reverse {
+		fg, bg = bg, fg
+	}
+	var css []string
+	if fg != "" {
+		css = append(css, "color:"+fg)
+	}
+	if bg != "" {
+		css = append(css, "background-color:"+bg)
+	}
+	if s.bold {
+		css = append(css, "font-weight:bold")
+	}
+	if s.faint {
+		css = append(css, "opacity:0.7")
+	}
+	if s.italic {
+		css = append(css, "font-style:italic")
+	}
+	if s.underline && s.strikethrough {
+		css = append(css, "text-decoration:underline line-through")
+	} else if s.underline {
+		css = append(css, "text-decoration:underline")
+	} else if s.strikethrough {
+		css = append(css, "text-decoration:line-through")
+	}
+	if s.invisible {
+		css = append(css, "visibility:hidden")
+	}
+	return strings.Join(css, ";")
+}
+
+// ANSIToHTML converts text styled with SGR escape sequences to HTML spans
+// with inline styles. Other escape sequences, such as hyperlinks, are
+// dropped.
+func ANSIToHTML(s string) string {
+	var out strings.Builder
+	var style htmlStyle
+	open := false
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		out.WriteString(html.EscapeString(text.String()))
+		text.Reset()
+	}
+	setStyle := func(next htmlStyle) {
+		if next == style {
+			return
+		}
+		flush()
+		if open {
+			out.WriteString("</span>")
+			open = false
+		}
+		style = next
+		if css := style.css(); css != "" {
+			fmt.Fprintf(&out, `<span style="%s">`, css)
+			open = true
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' || i+1 >= len(s) {
+			text.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '[':
+			// CSI: parameters up to a final byte in 0x40-0x7e
+			end := i + 2
+			for end < len(s) && (s[end] < 0x40 || s[end] > 0x7e) {
+				end++
+			}
+			if end == len(s) {
+				i = end
+				continue
+			}
+			if s[end] == 'm' {
+				setStyle(applySGR(style, s[i+2:end]))
+			}
+			i = end
+		case ']':
+			// OSC: up to BEL or ST
+			end := i + 2
+			for end < len(s) && s[end] != '\a' && !(s[end] == '\x1b' && end+1 < len(s) && s[end+1] == '\\') {
+				end++
+			}
+			if end < len(s) && s[end] == '\x1b' {
+				end++
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	flush()
+	if open {
+		out.WriteString("</span>")
+	}
+	return out.String()
+}
+
+func applySGR(style htmlStyle, params string) htmlStyle {
+	if params == "" {
+		return htmlStyle{}
+	}
+	codes := strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' })
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case code == 0:
+			style = htmlStyle{}
+		case code == 1:
+			style.bold = true
+		case code == 2:
+			style.faint = true
+		case code == 3:
+			style.italic = true
+		case code == 4:
+			style.underline = true
+		case code == 7:
+			style.reverse = true
+		case code == 8:
+			style.invisible = true
+		case code == 9:
+			style.strikethrough = true
+		case code == 22:
+			style.bold, style.faint = false, false
+		case code == 23:
+			style.italic = false
+		case code == 24:
+			style.underline = false
+		case code == 27:
+			style.reverse = false
+		case code == 28:
+			style.invisible = false
+		case code == 29:
+			style.strikethrough = false
+		case code >= 30 && code <= 37:
+			style.fg = ansi16[code-30]
+		case code >= 90 && code <= 97:
+			style.fg = ansi16[code-90+8]
+		case code == 39:
+			style.fg = ""
+		case code >= 40 && code <= 47:
+			style.bg = ansi16[code-40]
+		case code >= 100 && code <= 107:
+			style.bg = ansi16[code-100+8]
+		case code == 49:
+			style.bg = ""
+		case code == 38 || code == 48:
+			c, n := sgrColor(codes[i+1:])
+			i += n
+			if code == 38 {
+				style.fg = c
+			} else {
+				style.bg = c
+			}
+		}
+	}
+	return style
+}
+
+// sgrColor parses the extended color following 38 or 48 and returns it with
+// the number of parameters it used.
+func sgrColor(params []string) (string, int) {
+	if len(params) == 0 {
+		return "", 0
+	}
+	values := make([]int, 0, 4)
+	for _, param := range params[:min(len(params), 4)] {
+		value, _ := strconv.Atoi(param)
+		values = append(values, value)
+	}
+	switch {
+	case values[0] == 2 && len(values) >= 4:
+		return fmt.Sprintf("#%02x%02x%02x", values[1], values[2], values[3]), 4
+	case values[0] == 5 && len(values) >= 2:
+		return ansi256(values[1]), 2
+	}
+	return "", 1
+}
+
+func ansi256(index int) string {
+	switch {
+	case index < 0:
+		return ""
+	case index < 16:
+		return ansi16[index]
+	case index < 232:
+		index -= 16
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		return fmt.Sprintf("#%02x%02x%02x", levels[index/36], levels[index/6%6], levels[index%6])
+	case index < 256:
+		gray := 8 + (index-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+	return ""
+}
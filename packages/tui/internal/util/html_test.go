@@ -0,0 +1,56 @@
+package util
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestANSIToHTML(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "hello", want: "hello"},
+		{name: "escaping", in: `<a href="x">&</a>`, want: "&lt;a href=&#34;x&#34;&gt;&amp;&lt;/a&gt;"},
+		{name: "escaping in a span", in: "\x1b[1m<b>\x1b[0m", want: `<span style="font-weight:bold">&lt;b&gt;</span>`},
+		{name: "reset", in: "\x1b[1mbold\x1b[0m plain", want: `<span style="font-weight:bold">bold</span> plain`},
+		{name: "empty reset", in: "\x1b[3mitalic\x1b[m plain", want: `<span style="font-style:italic">italic</span> plain`},
+		{name: "unclosed", in: "\x1b[1mbold", want: `<span style="font-weight:bold">bold</span>`},
+		{name: "bold and italic", in: "\x1b[1;3mboth\x1b[22mitalic", want: `<span style="font-weight:bold;font-style:italic">both</span><span style="font-style:italic">italic</span>`},
+		{name: "underline and strikethrough", in: "\x1b[4;9mx", want: `<span style="text-decoration:underline line-through">x</span>`},
+		{name: "16 colors", in: "\x1b[31;42mx\x1b[39;49my", want: `<span style="color:#800000;background-color:#008000">x</span>y`},
+		{name: "bright colors", in: "\x1b[91;104mx", want: `<span style="color:#ff0000;background-color:#0000ff">x</span>`},
+		{name: "256 colors", in: "\x1b[38;5;196;48;5;244mx", want: `<span style="color:#ff0000;background-color:#808080">x</span>`},
+		{name: "256 basic colors", in: "\x1b[38;5;4mx", want: `<span style="color:#000080">x</span>`},
+		{name: "truecolor", in: "\x1b[38;2;1;2;3;48;2;250;251;252mx", want: `<span style="color:#010203;background-color:#fafbfc">x</span>`},
+		{name: "truecolor with colons", in: "\x1b[38:2:1:2:3mx", want: `<span style="color:#010203">x</span>`},
+		{name: "reverse", in: "\x1b[31;7mx", want: `<span style="background-color:#800000">x</span>`},
+		{name: "nested styles", in: "a\x1b[1mb\x1b[31mc\x1b[39md\x1b[0me", want: `a<span style="font-weight:bold">b</span><span style="color:#800000;font-weight:bold">c</span><span style="font-weight:bold">d</span>e`},
+		{name: "repeated style", in: "\x1b[1ma\x1b[1mb", want: `<span style="font-weight:bold">ab</span>`},
+		{name: "other CSI dropped", in: "a\x1b[2Kb", want: "ab"},
+		{name: "hyperlink dropped", in: "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x07", want: "link"},
+		{name: "truncated sequence", in: "a\x1b[1", want: "a"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ANSIToHTML(tt.in); got != tt.want {
+				t.Errorf("ANSIToHTML(%q) =\n%s\nwant\n%s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSSColor(t *testing.T) {
+	for _, tt := range []struct {
+		color color.Color
+		want  string
+	}{
+		{color: nil, want: "inherit"},
+		{color: color.RGBA{}, want: "inherit"},
+		{color: color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}, want: "#123456"},
+	} {
+		if got := CSSColor(tt.color); got != tt.want {
+			t.Errorf("CSSColor(%v) = %q, want %q", tt.color, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
@@ -45,3 +46,18 @@ func Measure(tag string) func(...any) {
 		slog.Debug(tag, args...)
 	}
 }
+
+// FormatTokens formats a token count for humans, e.g. 110K or 1.2M.
+func FormatTokens(tokens float64) string {
+	var formatted string
+	switch {
+	case tokens >= 1_000_000:
+		formatted = fmt.Sprintf("%.1fM", tokens/1_000_000)
+	case tokens >= 1_000:
+		formatted = fmt.Sprintf("%.1fK", tokens/1_000)
+	default:
+		return fmt.Sprintf("%d", int(tokens))
+	}
+	// Remove .0 suffix if present
+	return strings.Replace(formatted, ".0", "", 1)
+}